@@ -0,0 +1,141 @@
+package flattener
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// billionLaughs returns a document where each level aliases the previous one n times
+func billionLaughs(levels, n int) string {
+	var b strings.Builder
+	b.WriteString("l0: &l0 [lol]\n")
+	for i := 1; i <= levels; i++ {
+		aliases := make([]string, n)
+		for j := range aliases {
+			aliases[j] = fmt.Sprintf("*l%d", i-1)
+		}
+		fmt.Fprintf(&b, "l%d: &l%d [%s]\n", i, i, strings.Join(aliases, ", "))
+	}
+	return b.String()
+}
+
+// mergeLaughs is billionLaughs with merge keys, which produce few keys but take
+// exponential work to resolve
+func mergeLaughs(levels, n int) string {
+	var b strings.Builder
+	b.WriteString("m0: &m0 {a: 1}\n")
+	for i := 1; i <= levels; i++ {
+		aliases := make([]string, n)
+		for j := range aliases {
+			aliases[j] = fmt.Sprintf("*m%d", i-1)
+		}
+		fmt.Fprintf(&b, "m%d: &m%d {<<: [%s]}\n", i, i, strings.Join(aliases, ", "))
+	}
+	return b.String()
+}
+
+func TestFlattenAliasExpansionBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		yamlStr string
+	}{
+		{name: "Sequence aliases", yamlStr: billionLaughs(9, 9)},
+		{name: "Merge keys", yamlStr: mergeLaughs(9, 9)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := New().FlattenString(tt.yamlStr)
+			assertErrorType(t, err, ErrTypeAlias)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("expansion took %v, expected the budget to stop it early", elapsed)
+			}
+		})
+	}
+}
+
+func TestFlattenAliasExpansionCount(t *testing.T) {
+	yamlStr := "base: &base {a: 1, b: 2}\ncopy: *base\n"
+
+	f := New()
+	f.MaxAliasExpansions = 3
+	if _, err := f.FlattenString(yamlStr); err != nil {
+		t.Fatalf("expected the mapping and its two values to fit the budget, got %v", err)
+	}
+
+	f.MaxAliasExpansions = 2
+	_, err := f.FlattenString(yamlStr)
+	assertErrorType(t, err, ErrTypeAlias)
+	if fe, ok := err.(*Error); !ok || fe.Path != "copy.b" {
+		t.Errorf("expected the error at copy.b, got %v", err)
+	}
+}
+
+func TestFlattenRejectAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		yamlStr string
+		wantErr bool
+	}{
+		{name: "Alias value", yamlStr: "a: &x 1\nb: *x\n", wantErr: true},
+		{name: "Merge of an alias", yamlStr: "a: &x {k: 1}\nb:\n  <<: *x\n", wantErr: true},
+		{name: "Merge of an alias in a sequence", yamlStr: "a: &x {k: 1}\nb:\n  <<: [*x]\n", wantErr: true},
+		{name: "Alias key", yamlStr: "a: &x k\nb:\n  *x : 1\n", wantErr: true},
+		{name: "Anchor without alias", yamlStr: "a: &x 1\n"},
+		{name: "Inline merge", yamlStr: "b:\n  <<: {k: 1}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.RejectAliases = true
+
+			_, err := f.FlattenString(tt.yamlStr)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			assertErrorType(t, err, ErrTypeAlias)
+			if !strings.Contains(err.Error(), "alias *x is not allowed") {
+				t.Errorf("unexpected message: %v", err)
+			}
+		})
+	}
+}
+
+func TestFlattenAnchors(t *testing.T) {
+	yamlStr := `
+defaults: &defaults
+  timeout: 30
+  tls: &tls
+    enabled: true
+service:
+  <<: *defaults
+  name: api
+  security: *tls
+plain:
+  <<: {inline: 1}
+`
+
+	f := New()
+	f.KeyTransforms = []KeyTransform{KeyTransformUpper}
+	result, err := f.FlattenString(yamlStr)
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"SERVICE.TIMEOUT":          "defaults",
+		"SERVICE.TLS.ENABLED":      "defaults",
+		"SERVICE.SECURITY.ENABLED": "tls",
+	}
+	if !reflect.DeepEqual(result.Anchors, expected) {
+		t.Errorf("Anchors = %v, want %v", result.Anchors, expected)
+	}
+}
@@ -0,0 +1,63 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import "fmt"
+
+// KeyCollisions controls what happens when two values flatten to the same key, such as
+// "a.b": x next to a: {b: y}, or keys that only differ in whitespace or control
+// characters removed by sanitizing
+type KeyCollisions string
+
+const (
+	// KeyCollisionsError fails on the second value of a key
+	KeyCollisionsError KeyCollisions = "error"
+	// KeyCollisionsWarn keeps the first value and records a warning in Result.Warnings
+	KeyCollisionsWarn KeyCollisions = "warn"
+	// KeyCollisionsFirstWins keeps the first value in document order
+	KeyCollisionsFirstWins KeyCollisions = "first_wins"
+	// KeyCollisionsLastWins keeps the last value in document order
+	KeyCollisionsLastWins KeyCollisions = "last_wins"
+	// KeyCollisionsEscape escapes object keys with KeyEscaping, or KeyEscapingBackslash
+	// when KeyEscaping is none, so "a.b" flattens to a\.b and no longer collides with
+	// a: {b: y}. Keys that still collide after sanitizing are an error.
+	KeyCollisionsEscape KeyCollisions = "escape"
+)
+
+// store sets the value of key unless the key is already set, in which case KeyCollisions
+// decides. It reports whether the value was stored.
+func (w *walker) store(key, value string, valueType ValueType) (bool, error) {
+	return w.storeWith(key, value, valueType, w.collisionMessage)
+}
+
+// storeWith is store with describe naming a collision on key in warnings and errors
+func (w *walker) storeWith(key, value string, valueType ValueType, describe func(key string) string) (bool, error) {
+	if _, exists := w.result.Values[key]; exists {
+		switch w.KeyCollisions {
+		case KeyCollisionsFirstWins:
+			return false, nil
+		case KeyCollisionsWarn:
+			w.result.Warnings = append(w.result.Warnings, describe(key)+", keeping the first value")
+			return false, nil
+		case KeyCollisionsLastWins:
+			delete(w.result.Locations, key)
+			delete(w.result.Anchors, key)
+			delete(w.result.Sources, key)
+		default:
+			return false, ValidationError(describe(key), nil)
+		}
+	}
+	w.result.set(key, value, valueType)
+	return true, nil
+}
+
+// collisionMessage describes a second value for key, naming the position of the first
+// when it is known
+func (w *walker) collisionMessage(key string) string {
+	message := fmt.Sprintf("key %q is set more than once", key)
+	if location, ok := w.result.Locations[key]; ok && location.File != "" {
+		message += fmt.Sprintf(" (first set at %s:%d)", location.File, location.Line)
+	} else if ok {
+		message += fmt.Sprintf(" (first set on line %d)", location.Line)
+	}
+	return message
+}
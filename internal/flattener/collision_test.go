@@ -0,0 +1,117 @@
+package flattener
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenKeyCollisions(t *testing.T) {
+	yamlStr := `"a.b": dotted
+a:
+  b: nested
+  c: other
+`
+
+	tests := []struct {
+		policy   KeyCollisions
+		expected map[string]string
+		warnings int
+		wantErr  bool
+	}{
+		{policy: KeyCollisionsError, wantErr: true},
+		{policy: KeyCollisionsWarn, expected: map[string]string{"a.b": "dotted", "a.c": "other"}, warnings: 1},
+		{policy: KeyCollisionsFirstWins, expected: map[string]string{"a.b": "dotted", "a.c": "other"}},
+		{policy: KeyCollisionsLastWins, expected: map[string]string{"a.b": "nested", "a.c": "other"}},
+		{policy: KeyCollisionsEscape, expected: map[string]string{`a\.b`: "dotted", "a.b": "nested", "a.c": "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			f := New()
+			f.KeyCollisions = tt.policy
+
+			result, err := f.FlattenString(yamlStr)
+			if tt.wantErr {
+				assertErrorType(t, err, ErrTypeValidation)
+				var fe *Error
+				if errors.As(err, &fe) && (fe.Path != "a.b" || fe.Line != 3) {
+					t.Errorf("expected error at a.b on line 3, got %v", err)
+				}
+				if !strings.Contains(err.Error(), "first set on line 1") {
+					t.Errorf("expected error to name the first value, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FlattenString() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("Values = %v, want %v", result.Values, tt.expected)
+			}
+			if len(result.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %v, want %d", result.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestFlattenKeyCollisionsLocations(t *testing.T) {
+	f := New()
+	f.KeyCollisions = KeyCollisionsLastWins
+
+	result, err := f.FlattenString("\"a.b\": 1\na:\n  b: 2\n")
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+	if result.Locations["a.b"] != (Location{Line: 3, Column: 6}) {
+		t.Errorf("Locations[a.b] = %v, want the last value", result.Locations["a.b"])
+	}
+	if !reflect.DeepEqual(result.Keys, []string{"a.b"}) {
+		t.Errorf("Keys = %v", result.Keys)
+	}
+
+	// keys that only differ in whitespace collide after sanitizing, also when escaping
+	f.KeyCollisions = KeyCollisionsEscape
+	_, err = f.FlattenJSON(`{"key": 1, "key ": 2}`)
+	assertErrorType(t, err, ErrTypeValidation)
+
+	// the warnings survive the key transforms
+	f.KeyCollisions = KeyCollisionsWarn
+	f.KeyTransforms = []KeyTransform{KeyTransformUpper}
+	result, err = f.FlattenJSON(`{"key": 1, "key ": 2}`)
+	if err != nil {
+		t.Fatalf("FlattenJSON() error = %v", err)
+	}
+	if result.Values["KEY"] != "1" || len(result.Warnings) != 1 {
+		t.Errorf("FlattenJSON() = %v with warnings %v", result.Values, result.Warnings)
+	}
+}
+
+func TestUnflattenEscapedKeys(t *testing.T) {
+	f := New()
+	f.KeyCollisions = KeyCollisionsEscape
+
+	yamlStr := "\"a.b\": dotted\na:\n  b: nested\n\"back\\\\slash\": x\n"
+	flat, err := f.FlattenYAMLString(yamlStr)
+	if err != nil {
+		t.Fatalf("FlattenYAMLString() error = %v", err)
+	}
+	if flat[`back\\slash`] != "x" {
+		t.Errorf("expected escaped backslash key, got %v", flat)
+	}
+
+	data, err := f.Unflatten(flat)
+	if err != nil {
+		t.Fatalf("Unflatten() error = %v", err)
+	}
+	expected := map[string]interface{}{
+		"a.b":        "dotted",
+		"a":          map[string]interface{}{"b": "nested"},
+		`back\slash`: "x",
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Unflatten() = %v, want %v", data, expected)
+	}
+}
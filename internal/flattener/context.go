@@ -0,0 +1,68 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ContextDecoder is implemented by decoders that stop when their context is cancelled.
+// FlattenFormatContext uses it instead of Decode when a format's Decoder implements it.
+type ContextDecoder interface {
+	DecodeContext(ctx context.Context, content string) (interface{}, error)
+}
+
+// ContextDecoderFunc adapts an ordinary function to the Decoder and ContextDecoder
+// interfaces
+type ContextDecoderFunc func(ctx context.Context, content string) (interface{}, error)
+
+// Decode calls fn with a background context
+func (fn ContextDecoderFunc) Decode(content string) (interface{}, error) {
+	return fn(context.Background(), content)
+}
+
+// DecodeContext calls fn(ctx, content)
+func (fn ContextDecoderFunc) DecodeContext(ctx context.Context, content string) (interface{}, error) {
+	return fn(ctx, content)
+}
+
+// withTimeout bounds ctx by the Timeout of the Flattener, if one is set
+func (f *Flattener) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, f.Timeout)
+}
+
+// contextError converts the error of a done context into a TimeoutError or
+// CancelledError for operation
+func contextError(ctx context.Context, operation string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return TimeoutError(operation)
+	}
+	return CancelledError(operation)
+}
+
+// contextReader reads a string until its context is done, so that streaming decoders
+// stop parsing at the next read
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func newContextReader(ctx context.Context, content string) io.Reader {
+	return &contextReader{ctx: ctx, r: strings.NewReader(content)}
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	// read in small chunks so that cancellation is noticed while parsing large content
+	if len(p) > 4096 {
+		p = p[:4096]
+	}
+	return cr.r.Read(p)
+}
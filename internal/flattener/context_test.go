@@ -0,0 +1,157 @@
+package flattener
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// largeYAML returns a document with n keys, large enough for several context checks
+func largeYAML(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "key%d: value%d\n", i, i)
+	}
+	return b.String()
+}
+
+func TestFlattenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		call  func(f *Flattener) error
+		error string
+	}{
+		{
+			name: "yaml string",
+			call: func(f *Flattener) error {
+				_, err := f.FlattenYAMLStringContext(ctx, "key: value")
+				return err
+			},
+			error: "YAML parsing was cancelled",
+		},
+		{
+			name: "json string",
+			call: func(f *Flattener) error {
+				_, err := f.FlattenJSONStringContext(ctx, `{"key": "value"}`)
+				return err
+			},
+			error: "JSON parsing was cancelled",
+		},
+		{
+			name: "toml",
+			call: func(f *Flattener) error {
+				_, err := f.FlattenFormatContext(ctx, "key = 'value'", FormatTOML)
+				return err
+			},
+			error: "TOML parsing was cancelled",
+		},
+		{
+			name: "documents",
+			call: func(f *Flattener) error {
+				_, err := f.FlattenDocumentsContext(ctx, "a: 1\n---\nb: 2\n")
+				return err
+			},
+			error: "YAML parsing was cancelled",
+		},
+		{
+			name: "prefixed documents",
+			call: func(f *Flattener) error {
+				_, err := f.FlattenDocumentsPrefixedContext(ctx, "a: 1\n---\nb: 2\n", "")
+				return err
+			},
+			error: "YAML parsing was cancelled",
+		},
+		{
+			name: "traversal",
+			call: func(f *Flattener) error {
+				_, err := f.FlattenContext(ctx, map[string]interface{}{"key": "value"})
+				return err
+			},
+			error: "flattening was cancelled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(New())
+			assertErrorType(t, err, ErrTypeCancelled)
+			if !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestFlattenContextCancelledDuringTraversal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := New()
+	node, err := decodeYAML(ctx, largeYAML(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w, err := f.newWalker(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.visited = 1 // skip the check on the first value, so the walk stops at the next one
+	cancel()
+
+	err = w.flattenValueWithDepth(node, "", 0, true)
+	assertErrorType(t, err, ErrTypeCancelled)
+	if len(w.result.Values) == 0 || len(w.result.Values) >= 1000 {
+		t.Errorf("expected traversal to stop part way, got %d values", len(w.result.Values))
+	}
+}
+
+func TestFlattenContextCancelledAfterDecode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// decoders without DecodeContext run to completion and the context is checked after
+	RegisterFormat(Format{Name: "slow", Decoder: DecoderFunc(func(content string) (interface{}, error) {
+		cancel()
+		return map[string]interface{}{"key": content}, nil
+	})})
+
+	_, err := New().FlattenFormatContext(ctx, "value", "slow")
+	assertErrorType(t, err, ErrTypeCancelled)
+}
+
+func TestFlattenTimeout(t *testing.T) {
+	f := New()
+	f.Timeout = time.Nanosecond
+
+	_, err := f.FlattenYAMLStringContext(context.Background(), largeYAML(1000))
+	assertErrorType(t, err, ErrTypeTimeout)
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout message, got %v", err)
+	}
+
+	// a deadline of the caller's context is reported as a timeout as well
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, err = New().FlattenYAMLStringContext(ctx, "key: value")
+	assertErrorType(t, err, ErrTypeTimeout)
+}
+
+func TestFlattenTimeoutDisabled(t *testing.T) {
+	f := New()
+	f.Timeout = 0
+
+	result, err := f.FlattenYAMLStringContext(context.Background(), largeYAML(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1000 {
+		t.Errorf("expected 1000 values, got %d", len(result))
+	}
+}
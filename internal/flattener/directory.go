@@ -0,0 +1,195 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// FlattenDirectory flattens every file below root whose path relative to root matches
+// an include pattern and no exclude pattern, and returns the result of each file keyed
+// by that relative path, using / as path separator.
+//
+// Patterns are globs over relative paths, where * matches within one directory and **
+// matches any number of directories (e.g. "services/**/*.yaml"), or regular expressions
+// prefixed with "regex:". Without include patterns every file with a registered format
+// extension is flattened. A directory matching an exclude pattern is not walked.
+// Symbolic links are not followed. Files are flattened as the format of their extension,
+// falling back to YAML, with the path and size checks of FlattenYAMLFile, and
+// MaxResultSize applies to the total number of values across all files.
+func (f *Flattener) FlattenDirectory(root string, include, exclude []string) (map[string]*Result, error) {
+	return f.FlattenDirectoryContext(context.Background(), root, include, exclude)
+}
+
+// FlattenDirectoryContext is FlattenDirectory with a context that stops walking,
+// parsing and flattening when it is done. Timeout applies to the whole directory.
+func (f *Flattener) FlattenDirectoryContext(ctx context.Context, root string, include, exclude []string) (map[string]*Result, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	files, err := f.directoryFiles(ctx, root, include, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*Result, len(files))
+	total := 0
+	for _, file := range files {
+		name := filepath.Join(root, filepath.FromSlash(file))
+		r, err := f.FlattenFileFormatContext(ctx, name, "")
+		if err != nil {
+			return nil, inDirectory(err, name)
+		}
+		total += len(r.Values)
+		if total > f.MaxResultSize {
+			return nil, SizeLimitError(f.MaxResultSize, "result").limitedBy("MaxResultSize")
+		}
+		results[file] = r
+	}
+
+	return results, nil
+}
+
+// FlattenDirectoryPrefixed flattens the files selected as by FlattenDirectory into one
+// result, prefixing the keys of each file with its relative path without extension,
+// e.g. "services/api.database.host" for database.host in services/api.yaml. The path is
+// one object key, escaped according to KeyEscaping, so with a separator that appears in
+// paths the keys of a/b.yaml do not collide with those of a.yaml. Files are flattened in
+// path order, and two files that would share a prefix are an error.
+func (f *Flattener) FlattenDirectoryPrefixed(root string, include, exclude []string) (*Result, error) {
+	return f.FlattenDirectoryPrefixedContext(context.Background(), root, include, exclude)
+}
+
+// FlattenDirectoryPrefixedContext is FlattenDirectoryPrefixed with a context that stops
+// walking, parsing and flattening when it is done. Timeout applies to the whole
+// directory.
+func (f *Flattener) FlattenDirectoryPrefixedContext(ctx context.Context, root string, include, exclude []string) (*Result, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	files, err := f.directoryFiles(ctx, root, include, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := f.newWalker(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]string, len(files))
+	for _, file := range files {
+		prefix := f.objectKey(file[:len(file)-len(path.Ext(file))])
+		if previous, ok := seen[prefix]; ok {
+			return nil, ValidationError(fmt.Sprintf("files %q and %q have the same key prefix %q", previous, file, prefix), nil)
+		}
+		seen[prefix] = file
+
+		name := filepath.Join(root, filepath.FromSlash(file))
+		content, formatName, err := f.readFileFormat(name, "")
+		if err != nil {
+			return nil, inDirectory(err, name)
+		}
+
+		// the walker reads SourceFile through its Flattener, so every file gets its own
+		w.Flattener = f.withSourceFile(name)
+		data, err := w.decodeFormat(ctx, content, formatName)
+		if err != nil {
+			return nil, inDirectory(err, name)
+		}
+		if err := w.flattenValueWithDepth(data, prefix, 0, w.filter.includeAll()); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.finish()
+}
+
+// directoryFiles returns the relative paths of the files below root selected by the
+// include and exclude patterns, in lexical order
+func (f *Flattener) directoryFiles(ctx context.Context, root string, include, exclude []string) ([]string, error) {
+	absRoot, err := f.checkPath(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, FileAccessError(fmt.Sprintf("failed to access directory: %s", err), err)
+	}
+	if !info.IsDir() {
+		return nil, FileAccessError(fmt.Sprintf("%s is not a directory", root), nil)
+	}
+
+	includes, err := compilePatterns(include, "include", "/")
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compilePatterns(exclude, "exclude", "/")
+	if err != nil {
+		return nil, err
+	}
+	matchesAny := func(patterns []*keyPattern, file string) bool {
+		for _, p := range patterns {
+			if p.match(file, "/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var files []string
+	err = filepath.WalkDir(absRoot, func(name string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return contextError(ctx, "directory walk")
+		}
+		if err != nil {
+			return FileAccessError(fmt.Sprintf("failed to read directory: %s", err), err)
+		}
+		if name == absRoot {
+			return nil
+		}
+
+		rel, err := filepath.Rel(absRoot, name)
+		if err != nil {
+			return FileAccessError(fmt.Sprintf("invalid file path: %s", err), err)
+		}
+		file := filepath.ToSlash(rel)
+
+		switch {
+		case entry.IsDir():
+			if matchesAny(excludes, file) {
+				return filepath.SkipDir
+			}
+		case !entry.Type().IsRegular(), matchesAny(excludes, file):
+		case len(includes) == 0:
+			if _, ok := FormatForPath(file); ok {
+				files = append(files, file)
+			}
+		case matchesAny(includes, file):
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// inDirectory sets the file of an error for one file of a directory, unless it is
+// already set
+func inDirectory(err error, file string) error {
+	var fe *Error
+	if errors.As(err, &fe) && fe.File == "" {
+		fe.File = file
+	}
+	return err
+}
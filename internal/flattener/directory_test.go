@@ -0,0 +1,183 @@
+package flattener
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the files of tree below a new temporary directory
+func writeTree(t *testing.T, tree map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range tree {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFlattenDirectory(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"app.yaml":                "name: app\n",
+		"services/api.yaml":       "database:\n  host: db\n",
+		"services/worker.json":    `{"queue": "jobs"}`,
+		"services/cache.toml":     "size = 10\n",
+		"services/README.md":      "# not config\n",
+		"services/old/api.yaml":   "legacy: true\n",
+		"vendor/chart/values.yml": "ignored: true\n",
+	})
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "registered extensions by default",
+			expected: []string{"app.yaml", "services/api.yaml", "services/cache.toml", "services/old/api.yaml", "services/worker.json", "vendor/chart/values.yml"},
+		},
+		{
+			name:     "include glob",
+			include:  []string{"services/*.yaml"},
+			expected: []string{"services/api.yaml"},
+		},
+		{
+			name:     "any directory",
+			include:  []string{"**/api.yaml"},
+			expected: []string{"services/api.yaml", "services/old/api.yaml"},
+		},
+		{
+			name:     "excluded directory",
+			exclude:  []string{"vendor/**", "**/old/**"},
+			expected: []string{"app.yaml", "services/api.yaml", "services/cache.toml", "services/worker.json"},
+		},
+		{
+			name:     "regex",
+			include:  []string{`regex:\.(json|toml)$`},
+			expected: []string{"services/cache.toml", "services/worker.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := New().FlattenDirectory(root, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			files := make([]string, 0, len(results))
+			for file := range results {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tt.expected) {
+				t.Errorf("expected files %v, got %v", tt.expected, files)
+			}
+		})
+	}
+
+	results, err := New().FlattenDirectory(root, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results["services/api.yaml"].Values["database.host"] != "db" || results["services/worker.json"].Values["queue"] != "jobs" || results["services/cache.toml"].Types["size"] != ValueTypeNumber {
+		t.Errorf("unexpected results %v", results)
+	}
+	location := results["services/api.yaml"].Locations["database.host"]
+	if location.File != filepath.Join(root, "services", "api.yaml") || location.Line != 2 {
+		t.Errorf("expected database.host located in services/api.yaml, got %v", location)
+	}
+}
+
+func TestFlattenDirectoryPrefixed(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"app.yaml":          "name: app\n",
+		"services/api.yaml": "database:\n  host: db\nports: [80]\n",
+	})
+
+	result, err := New().FlattenDirectoryPrefixed(root, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"app.name", "services/api.database.host", "services/api.ports[0]"}
+	if !reflect.DeepEqual(result.Keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, result.Keys)
+	}
+	if location := result.Locations["services/api.database.host"]; location.File != filepath.Join(root, "services", "api.yaml") || location.Line != 2 {
+		t.Errorf("expected location in services/api.yaml, got %v", location)
+	}
+
+	f := New()
+	f.Include = []string{"services/api.database.**"}
+	f.KeyTransforms = []KeyTransform{KeyTransformUpper}
+	result, err = f.FlattenDirectoryPrefixed(root, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Values, map[string]string{"SERVICES/API.DATABASE.HOST": "db"}) {
+		t.Errorf("expected filter and transforms on prefixed keys, got %v", result.Values)
+	}
+
+	nested := writeTree(t, map[string]string{
+		"a.yaml":   "b/c: 1\n",
+		"a/b.yaml": "c: 2\n",
+	})
+	f = New()
+	f.Separator = "/"
+	f.KeyEscaping = KeyEscapingBackslash
+	result, err = f.FlattenDirectoryPrefixed(nested, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Values, map[string]string{`a/b\/c`: "1", `a\/b/c`: "2"}) {
+		t.Errorf("expected escaped path prefixes, got %v", result.Values)
+	}
+}
+
+func TestFlattenDirectoryErrors(t *testing.T) {
+	collision := writeTree(t, map[string]string{"app.yaml": "a: 1\n", "app.json": `{"b": 2}`})
+	_, err := New().FlattenDirectoryPrefixed(collision, nil, nil)
+	assertErrorType(t, err, ErrTypeValidation)
+	if err == nil || !strings.Contains(err.Error(), `same key prefix "app"`) {
+		t.Errorf("expected key prefix collision, got %v", err)
+	}
+
+	invalid := writeTree(t, map[string]string{"ok.yaml": "a: 1\n", "bad.yaml": "a: [\n"})
+	_, err = New().FlattenDirectory(invalid, nil, nil)
+	assertErrorType(t, err, ErrTypeParsing)
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(invalid, "bad.yaml")) {
+		t.Errorf("expected error to name bad.yaml, got %v", err)
+	}
+
+	f := New()
+	f.MaxResultSize = 1
+	_, err = f.FlattenDirectory(collision, nil, nil)
+	assertErrorType(t, err, ErrTypeSizeLimit)
+
+	_, err = New().FlattenDirectory(filepath.Join(collision, "app.yaml"), nil, nil)
+	assertErrorType(t, err, ErrTypeFileAccess)
+
+	_, err = New().FlattenDirectory("config/../../etc", nil, nil)
+	assertErrorType(t, err, ErrTypePathSecurity)
+
+	_, err = New().FlattenDirectory(collision, []string{""}, nil)
+	assertErrorType(t, err, ErrTypeValidation)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New().FlattenDirectoryContext(ctx, collision, nil, nil)
+	if !errors.Is(err, CancelledError("")) {
+		t.Errorf("expected cancelled error, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// documentKeyPlaceholder matches {path} placeholders in a document key template
+var documentKeyPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// streamDocument is a non-empty document of a YAML stream and its position in the
+// stream, counting the empty documents that were skipped
+type streamDocument struct {
+	node  *yaml.Node
+	index int
+}
+
+// FlattenYAMLDocuments takes a multi-document YAML stream and flattens every document
+// into its own map with dot notation
+func (f *Flattener) FlattenYAMLDocuments(yamlContent string) ([]map[string]string, error) {
+	return f.FlattenYAMLDocumentsContext(context.Background(), yamlContent)
+}
+
+// FlattenYAMLDocumentsContext is FlattenYAMLDocuments with a context that stops parsing
+// and flattening when it is done
+func (f *Flattener) FlattenYAMLDocumentsContext(ctx context.Context, yamlContent string) ([]map[string]string, error) {
+	results, err := f.FlattenDocumentsContext(ctx, yamlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	maps := make([]map[string]string, len(results))
+	for i, r := range results {
+		maps[i] = r.Values
+	}
+	return maps, nil
+}
+
+// FlattenDocuments takes a multi-document YAML stream and flattens every document
+// separately. Empty documents are skipped. MaxResultSize applies to the total number
+// of values across all documents.
+func (f *Flattener) FlattenDocuments(yamlContent string) ([]*Result, error) {
+	return f.FlattenDocumentsContext(context.Background(), yamlContent)
+}
+
+// FlattenDocumentsContext is FlattenDocuments with a context that stops parsing and
+// flattening when it is done. Timeout applies to the whole stream.
+func (f *Flattener) FlattenDocumentsContext(ctx context.Context, yamlContent string) ([]*Result, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	docs, err := f.parseDocuments(ctx, yamlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.flattenDocuments(ctx, docs)
+}
+
+// flattenDocuments flattens every parsed document of a stream separately
+func (f *Flattener) flattenDocuments(ctx context.Context, docs []streamDocument) ([]*Result, error) {
+	results := make([]*Result, 0, len(docs))
+	total := 0
+	for _, doc := range docs {
+		r, err := f.FlattenContext(ctx, doc.node)
+		if err != nil {
+			return nil, err
+		}
+		total += len(r.Values)
+		if total > f.MaxResultSize {
+			return nil, SizeLimitError(f.MaxResultSize, "result").limitedBy("MaxResultSize")
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// FlattenDocumentsPrefixed takes a multi-document YAML stream and flattens all documents
+// into one result, prefixing the keys of each document with its document key.
+//
+// documentKey is a template where {index} is replaced by the position of the document
+// in the stream and any other {path} by the flattened value at that path in the document,
+// e.g. "{kind}/{metadata.name}". An empty template prefixes keys with the document index
+// in array notation, as if the stream were an array of documents. Positions count empty
+// documents too, so adding or removing an empty document does not rename later keys.
+func (f *Flattener) FlattenDocumentsPrefixed(yamlContent, documentKey string) (*Result, error) {
+	return f.FlattenDocumentsPrefixedContext(context.Background(), yamlContent, documentKey)
+}
+
+// FlattenDocumentsPrefixedContext is FlattenDocumentsPrefixed with a context that stops
+// parsing and flattening when it is done. Timeout applies to the whole stream.
+func (f *Flattener) FlattenDocumentsPrefixedContext(ctx context.Context, yamlContent, documentKey string) (*Result, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	docs, err := f.parseDocuments(ctx, yamlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.flattenDocumentsPrefixed(ctx, docs, documentKey)
+}
+
+// FlattenDocumentsAndPrefixedContext returns both the results of FlattenDocumentsContext
+// and of FlattenDocumentsPrefixedContext, parsing the stream only once. Timeout applies
+// to the whole call.
+func (f *Flattener) FlattenDocumentsAndPrefixedContext(ctx context.Context, yamlContent, documentKey string) ([]*Result, *Result, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	docs, err := f.parseDocuments(ctx, yamlContent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := f.flattenDocuments(ctx, docs)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefixed, err := f.flattenDocumentsPrefixed(ctx, docs, documentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, prefixed, nil
+}
+
+// flattenDocumentsPrefixed flattens all parsed documents of a stream into one result
+// with the keys of each document below its document key
+func (f *Flattener) flattenDocumentsPrefixed(ctx context.Context, docs []streamDocument, documentKey string) (*Result, error) {
+	w, err := f.newWalker(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]int, len(docs))
+	for _, doc := range docs {
+		prefix, err := f.documentPrefix(ctx, doc.node, doc.index, documentKey)
+		if err != nil {
+			return nil, err
+		}
+		if previous, ok := seen[prefix]; ok {
+			return nil, ValidationError(fmt.Sprintf("documents %d and %d have the same document key %q", previous, doc.index, prefix), nil)
+		}
+		seen[prefix] = doc.index
+
+		if err := w.flattenValueWithDepth(doc.node, prefix, 0, w.filter.includeAll()); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.finish()
+}
+
+// documentPrefix renders the document key template for the document at stream
+// position i. A rendered template is one object key, escaped like the keys inside the
+// document.
+func (f *Flattener) documentPrefix(ctx context.Context, doc interface{}, i int, documentKey string) (string, error) {
+	if documentKey == "" {
+		return f.indexKey("", i), nil
+	}
+
+	var values map[string]string
+	var lookupErr error
+	prefix := documentKeyPlaceholder.ReplaceAllStringFunc(documentKey, func(placeholder string) string {
+		path := placeholder[1 : len(placeholder)-1]
+		if path == "index" {
+			return strconv.Itoa(i)
+		}
+		if values == nil && lookupErr == nil {
+			// document key paths are looked up in the source keys, regardless of
+			// Include, Exclude and key transforms
+			unfiltered := *f
+			unfiltered.Include, unfiltered.Exclude = nil, nil
+			unfiltered.KeyTransforms, unfiltered.KeyIllegalChars, unfiltered.KeyPrefix = nil, "", ""
+			var r *Result
+			if r, lookupErr = unfiltered.FlattenContext(ctx, doc); lookupErr == nil {
+				values = r.Values
+			}
+		}
+		value, ok := values[path]
+		if !ok && lookupErr == nil {
+			lookupErr = ValidationError(fmt.Sprintf("document %d has no value at %q for document key %q", i, path, documentKey), nil)
+		}
+		return value
+	})
+	if lookupErr != nil {
+		return "", lookupErr
+	}
+
+	return f.objectKey(prefix), nil
+}
+
+// parseDocuments decodes every non-empty document of a YAML stream with its position
+// in the stream
+func (f *Flattener) parseDocuments(ctx context.Context, yamlContent string) ([]streamDocument, error) {
+	yamlContent, err := f.prepareContent(yamlContent, "YAML")
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []streamDocument
+
+	decoder := yaml.NewDecoder(newContextReader(ctx, yamlContent))
+	for index := 0; ; index++ {
+		doc := new(yaml.Node)
+		err := decoder.Decode(doc)
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, "YAML parsing")
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, inFile(yamlParsingError("failed to parse YAML content", err), f.SourceFile)
+		}
+		if !isEmptyDocument(doc) {
+			docs = append(docs, streamDocument{node: doc, index: index})
+		}
+	}
+
+	if len(docs) == 0 {
+		return nil, ValidationError("YAML content contains no documents", nil)
+	}
+
+	return docs, nil
+}
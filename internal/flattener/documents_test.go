@@ -0,0 +1,225 @@
+package flattener
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifests = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports: [80]
+---
+# comment-only documents are skipped
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`
+
+func TestFlattenYAMLDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		yamlStr  string
+		expected []map[string]string
+		wantErr  ErrorType
+	}{
+		{
+			name:    "Multiple documents",
+			yamlStr: testManifests,
+			expected: []map[string]string{
+				{"apiVersion": "v1", "kind": "Service", "metadata.name": "web", "spec.ports[0]": "80"},
+				{"apiVersion": "apps/v1", "kind": "Deployment", "metadata.name": "web", "spec.replicas": "2"},
+			},
+		},
+		{
+			name:     "Single document",
+			yamlStr:  "key: value",
+			expected: []map[string]string{{"key": "value"}},
+		},
+		{
+			name:    "Only empty documents",
+			yamlStr: "---\n---\n",
+			wantErr: ErrTypeValidation,
+		},
+		{
+			name:    "Invalid later document",
+			yamlStr: "a: 1\n---\nb: : c\n",
+			wantErr: ErrTypeParsing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().FlattenYAMLDocuments(tt.yamlStr)
+			if tt.wantErr != "" {
+				assertErrorType(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("FlattenYAMLDocuments() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenDocumentsPrefixed(t *testing.T) {
+	tests := []struct {
+		name        string
+		documentKey string
+		arrayStyle  ArrayStyle
+		keyEscaping KeyEscaping
+		expected    map[string]string
+		wantErr     bool
+	}{
+		{
+			name:       "Index prefix in brackets",
+			arrayStyle: ArrayStyleBrackets,
+			expected: map[string]string{
+				"[0].apiVersion": "v1", "[0].kind": "Service", "[0].metadata.name": "web", "[0].spec.ports[0]": "80",
+				"[2].apiVersion": "apps/v1", "[2].kind": "Deployment", "[2].metadata.name": "web", "[2].spec.replicas": "2",
+			},
+		},
+		{
+			name:       "Index prefix dotted",
+			arrayStyle: ArrayStyleDotted,
+			expected: map[string]string{
+				"0.apiVersion": "v1", "0.kind": "Service", "0.metadata.name": "web", "0.spec.ports.0": "80",
+				"2.apiVersion": "apps/v1", "2.kind": "Deployment", "2.metadata.name": "web", "2.spec.replicas": "2",
+			},
+		},
+		{
+			name:        "Kind and name prefix",
+			documentKey: "{kind}/{metadata.name}",
+			arrayStyle:  ArrayStyleBrackets,
+			expected: map[string]string{
+				"Service/web.apiVersion": "v1", "Service/web.kind": "Service", "Service/web.metadata.name": "web", "Service/web.spec.ports[0]": "80",
+				"Deployment/web.apiVersion": "apps/v1", "Deployment/web.kind": "Deployment", "Deployment/web.metadata.name": "web", "Deployment/web.spec.replicas": "2",
+			},
+		},
+		{
+			name:        "Escaped document key",
+			documentKey: "{kind}.{metadata.name}",
+			arrayStyle:  ArrayStyleBrackets,
+			keyEscaping: KeyEscapingBackslash,
+			expected: map[string]string{
+				`Service\.web.apiVersion`: "v1", `Service\.web.kind`: "Service", `Service\.web.metadata.name`: "web", `Service\.web.spec.ports[0]`: "80",
+				`Deployment\.web.apiVersion`: "apps/v1", `Deployment\.web.kind`: "Deployment", `Deployment\.web.metadata.name`: "web", `Deployment\.web.spec.replicas`: "2",
+			},
+		},
+		{
+			name:        "Duplicate document key",
+			documentKey: "{metadata.name}",
+			arrayStyle:  ArrayStyleBrackets,
+			wantErr:     true,
+		},
+		{
+			name:        "Missing path",
+			documentKey: "{metadata.namespace}",
+			arrayStyle:  ArrayStyleBrackets,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.ArrayStyle = tt.arrayStyle
+			if tt.keyEscaping != "" {
+				f.KeyEscaping = tt.keyEscaping
+			}
+
+			result, err := f.FlattenDocumentsPrefixed(testManifests, tt.documentKey)
+			if tt.wantErr {
+				assertErrorType(t, err, ErrTypeValidation)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("FlattenDocumentsPrefixed() = %v, want %v", result.Values, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenDocumentsPrefixedStreamPositions(t *testing.T) {
+	content := "a: 1\n---\n---\nb: 2\n"
+	tests := []struct {
+		name        string
+		documentKey string
+		expected    map[string]string
+	}{
+		{
+			name:     "Index prefix",
+			expected: map[string]string{"[0].a": "1", "[2].b": "2"},
+		},
+		{
+			name:        "Index placeholder",
+			documentKey: "{index}",
+			expected:    map[string]string{"0.a": "1", "2.b": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().FlattenDocumentsPrefixed(content, tt.documentKey)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("FlattenDocumentsPrefixed() = %v, want %v", result.Values, tt.expected)
+			}
+		})
+	}
+
+	_, err := New().FlattenDocumentsPrefixed("name: a\n---\n---\nname: a\n", "{name}")
+	if err == nil || !strings.Contains(err.Error(), "documents 0 and 2") {
+		t.Errorf("expected the stream positions in the error, got %v", err)
+	}
+}
+
+func TestFlattenDocumentsAndPrefixed(t *testing.T) {
+	f := New()
+
+	docs, prefixed, err := f.FlattenDocumentsAndPrefixedContext(context.Background(), testManifests, "{kind}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantDocs, err := f.FlattenDocuments(testManifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantPrefixed, err := f.FlattenDocumentsPrefixed(testManifests, "{kind}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(docs, wantDocs) {
+		t.Errorf("documents = %v, want %v", docs, wantDocs)
+	}
+	if !reflect.DeepEqual(prefixed, wantPrefixed) {
+		t.Errorf("prefixed = %v, want %v", prefixed, wantPrefixed)
+	}
+}
+
+func TestFlattenDocumentsSizeLimit(t *testing.T) {
+	f := New()
+	f.MaxResultSize = 3
+
+	_, err := f.FlattenDocuments("a: 1\nb: 2\n---\nc: 3\nd: 4\n")
+	assertErrorType(t, err, ErrTypeSizeLimit)
+}
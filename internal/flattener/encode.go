@@ -0,0 +1,131 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// dotenvBareValue matches values that can be written to a dotenv file without quotes
+var dotenvBareValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,-]+$`)
+
+// EncodeDotenv renders a flattened map as a dotenv file with one KEY=value line per key,
+// sorted by key. Values containing anything other than letters, digits and _./:@%+,-
+// are double-quoted, with backslashes, double quotes, backticks and $ escaped and
+// newlines written as \n, so that dotenv loaders neither split nor interpolate them.
+// Keys cannot be empty or contain whitespace, =, quotes, $, \ or #.
+func EncodeDotenv(flat map[string]string) (string, error) {
+	var b strings.Builder
+	for _, key := range sortedKeys(flat) {
+		if key == "" || strings.ContainsAny(key, " \t\r\n\f\v=\"'`$\\#") {
+			return "", ValidationError(fmt.Sprintf("key %q cannot be used in a dotenv file, keys cannot be empty or contain whitespace, =, quotes, $, \\ or #", key), nil)
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(quoteDotenv(flat[key]))
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func quoteDotenv(value string) string {
+	if dotenvBareValue.MatchString(value) {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\', '"', '$', '`':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// EncodeProperties renders a flattened map as a Java .properties file with one
+// key=value line per key, sorted by key. Escaping follows java.util.Properties.store:
+// backslashes, =, :, # and ! are escaped with a backslash, tabs, newlines, carriage
+// returns and form feeds are written as \t, \n, \r and \f, spaces are escaped in keys
+// and at the start of values, and characters outside printable ASCII are written as
+// \uXXXX so the file can be read as ISO-8859-1 or UTF-8.
+func EncodeProperties(flat map[string]string) (string, error) {
+	var b strings.Builder
+	for _, key := range sortedKeys(flat) {
+		writePropertiesEscaped(&b, key, true)
+		b.WriteByte('=')
+		writePropertiesEscaped(&b, flat[key], false)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func writePropertiesEscaped(b *strings.Builder, s string, isKey bool) {
+	for i, r := range s {
+		switch {
+		case r == ' ':
+			if isKey || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		case r == '\\' || r == '=' || r == ':' || r == '#' || r == '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(b, `\u%04X`, unit)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// EncodeJSON renders a flattened map as an indented JSON object with sorted keys.
+// HTML characters such as < and & are not escaped.
+func EncodeJSON(flat map[string]string) (string, error) {
+	if flat == nil {
+		flat = map[string]string{}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(flat); err != nil {
+		return "", ValidationError("failed to encode JSON", err)
+	}
+	return buf.String(), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package flattener
+
+import (
+	"testing"
+)
+
+func TestEncodeDotenv(t *testing.T) {
+	tests := []struct {
+		name     string
+		flat     map[string]string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "Sorted bare and quoted values",
+			flat: map[string]string{
+				"DB_PORT":  "5432",
+				"DB_HOST":  "db.example.com",
+				"GREETING": "hello world",
+				"EMPTY":    "",
+			},
+			expected: "DB_HOST=db.example.com\nDB_PORT=5432\nEMPTY=\"\"\nGREETING=\"hello world\"\n",
+		},
+		{
+			name: "Escaping",
+			flat: map[string]string{
+				"CERT":    "line1\nline2\r\n",
+				"QUOTED":  `say "hi" \ bye`,
+				"SECRET":  "pa$$word`cmd`",
+				"TABBED":  "a\tb",
+				"UNICODE": "café",
+			},
+			expected: `CERT="line1\nline2\r\n"` + "\n" +
+				`QUOTED="say \"hi\" \\ bye"` + "\n" +
+				"SECRET=\"pa\\$\\$word\\`cmd\\`\"\n" +
+				`TABBED="a\tb"` + "\n" +
+				`UNICODE="café"` + "\n",
+		},
+		{
+			name:     "Dotted keys",
+			flat:     map[string]string{"database.host": "db1"},
+			expected: "database.host=db1\n",
+		},
+		{
+			name:     "Empty map",
+			flat:     map[string]string{},
+			expected: "",
+		},
+		{
+			name:    "Key with equals sign",
+			flat:    map[string]string{"A=B": "1"},
+			wantErr: true,
+		},
+		{
+			name:    "Key with whitespace",
+			flat:    map[string]string{"A B": "1"},
+			wantErr: true,
+		},
+		{
+			name:    "Empty key",
+			flat:    map[string]string{"": "1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EncodeDotenv(tt.flat)
+			if tt.wantErr {
+				assertErrorType(t, err, ErrTypeValidation)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("EncodeDotenv() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEncodeProperties(t *testing.T) {
+	tests := []struct {
+		name     string
+		flat     map[string]string
+		expected string
+	}{
+		{
+			name: "Sorted keys",
+			flat: map[string]string{
+				"server.port": "8080",
+				"app.name":    "demo",
+			},
+			expected: "app.name=demo\nserver.port=8080\n",
+		},
+		{
+			name: "Separator and comment characters",
+			flat: map[string]string{
+				"url":        "jdbc:postgresql://db:5432/app?a=b",
+				"key:with=":  "#not a comment!",
+				"key with":   " leading and inner spaces",
+				`C:\path`:    `C:\temp`,
+				"multi.line": "a\nb\tc",
+			},
+			expected: `C\:\\path=C\:\\temp` + "\n" +
+				`key\ with=\ leading and inner spaces` + "\n" +
+				`key\:with\==\#not a comment\!` + "\n" +
+				`multi.line=a\nb\tc` + "\n" +
+				`url=jdbc\:postgresql\://db\:5432/app?a\=b` + "\n",
+		},
+		{
+			name:     "Non-ASCII characters",
+			flat:     map[string]string{"greeting": "café 😀"},
+			expected: `greeting=caf\u00E9 \uD83D\uDE00` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EncodeProperties(tt.flat)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("EncodeProperties() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEncodePropertiesRoundTrip(t *testing.T) {
+	flat := map[string]string{
+		"url":       "jdbc:postgresql://db:5432/app?a=b",
+		"key:with=": "#not a comment!",
+		"key with":  " leading spaces ",
+		`C:\path`:   "line1\nline2",
+		"unicode":   "café 😀",
+		"empty":     "",
+	}
+
+	encoded, err := EncodeProperties(flat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := New().FlattenFormat(encoded, FormatProperties)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for k, v := range flat {
+		if result.Values[k] != v {
+			t.Errorf("key %q = %q after round trip, want %q", k, result.Values[k], v)
+		}
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	result, err := EncodeJSON(map[string]string{
+		"b":       "<html> & more",
+		"a.b[0]":  "1",
+		"unicode": "café",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "{\n  \"a.b[0]\": \"1\",\n  \"b\": \"<html> & more\",\n  \"unicode\": \"café\"\n}\n"
+	if result != expected {
+		t.Errorf("EncodeJSON() = %q, want %q", result, expected)
+	}
+
+	empty, err := EncodeJSON(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if empty != "{}\n" {
+		t.Errorf("EncodeJSON(nil) = %q, want %q", empty, "{}\n")
+	}
+}
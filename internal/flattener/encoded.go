@@ -0,0 +1,107 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// compileEncodedKeys compiles the ExpandEncodedKeys patterns
+func (f *Flattener) compileEncodedKeys() ([]*keyPattern, error) {
+	return compilePatterns(f.ExpandEncodedKeys, "expand encoded keys", f.Separator)
+}
+
+// expandsEncoded reports whether the string value at key may be expanded
+func (w *walker) expandsEncoded(key string) bool {
+	if !w.ExpandEncoded {
+		return false
+	}
+	if len(w.encoded) == 0 {
+		return true
+	}
+	for _, p := range w.encoded {
+		if p.match(key, w.Separator) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandEncoded flattens a string value at prefix that holds a JSON or YAML object or
+// array in place of the string, and reports whether it did. The embedded values share
+// the depth and size limits and the alias budget of the outer document. They are
+// attributed to node, the scalar holding the string, when there is one.
+func (w *walker) expandEncoded(value interface{}, prefix string, depth int, included bool, node *yaml.Node) (bool, error) {
+	s, ok := value.(string)
+	if !ok || !w.expandsEncoded(prefix) {
+		return false, nil
+	}
+	decoded := decodeEncoded(w.ctx, s)
+	if decoded == nil {
+		return false, nil
+	}
+
+	first := len(w.result.Keys)
+	if err := w.flattenValueWithDepth(decoded, prefix, depth, included); err != nil {
+		return true, err
+	}
+
+	// positions inside the string mean nothing in the source, so the embedded values
+	// take the position of the string itself
+	for _, key := range w.result.Keys[first:] {
+		if node == nil {
+			delete(w.result.Locations, key)
+			continue
+		}
+		w.result.Locations[key] = Location{File: w.fileOf(node), Line: node.Line, Column: node.Column}
+		if layer, ok := w.origin[node]; ok {
+			w.result.Sources[key] = w.layers[layer]
+		}
+	}
+	return true, nil
+}
+
+// decodeEncoded decodes a string holding a JSON object or array, or a YAML mapping or
+// sequence spanning several lines, and returns nil for any other string. Single-line
+// strings are not read as YAML, as text such as "Note: restart required" would
+// otherwise become a mapping. Empty objects and arrays such as "[]" are kept as strings,
+// since under EmptyCollectionsOmit expanding them would remove the key.
+func decodeEncoded(ctx context.Context, s string) interface{} {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		data, err := decodeJSON(ctx, trimmed)
+		if err == nil {
+			switch v := data.(type) {
+			case *orderedMap:
+				if len(v.keys) > 0 {
+					return data
+				}
+			case []interface{}:
+				if len(v) > 0 {
+					return data
+				}
+			}
+		}
+	}
+
+	if !strings.Contains(trimmed, "\n") {
+		return nil
+	}
+	data, err := decodeYAML(ctx, s)
+	if err != nil || data == nil {
+		return nil
+	}
+	doc := data.(*yaml.Node)
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	switch root := resolveAlias(doc.Content[0]); root.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		if len(root.Content) > 0 {
+			return doc
+		}
+	}
+	return nil
+}
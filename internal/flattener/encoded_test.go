@@ -0,0 +1,139 @@
+package flattener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenExpandEncoded(t *testing.T) {
+	yamlStr := `policy: '{"Version": "2012-10-17", "Statement": [{"Effect": "Allow"}]}'
+data:
+  app.yaml: |
+    server:
+      port: 8080
+  motd: "Note: restart required"
+  list: '[1, 2]'
+  empty: '[]'
+  none: |
+    {}
+  broken: '{"a": '
+`
+
+	tests := []struct {
+		name     string
+		keys     []string
+		include  []string
+		expected map[string]string
+	}{
+		{
+			name: "every string",
+			expected: map[string]string{
+				"policy.Version":             "2012-10-17",
+				"policy.Statement[0].Effect": "Allow",
+				"data.app.yaml.server.port":  "8080",
+				"data.motd":                  "Note: restart required",
+				"data.list[0]":               "1",
+				"data.list[1]":               "2",
+				"data.empty":                 "[]",
+				"data.none":                  "{}\n",
+				"data.broken":                `{"a": `,
+			},
+		},
+		{
+			name: "restricted keys",
+			keys: []string{"policy"},
+			expected: map[string]string{
+				"policy.Version":             "2012-10-17",
+				"policy.Statement[0].Effect": "Allow",
+				"data.app.yaml":              "server:\n  port: 8080\n",
+				"data.motd":                  "Note: restart required",
+				"data.list":                  "[1, 2]",
+				"data.empty":                 "[]",
+				"data.none":                  "{}\n",
+				"data.broken":                `{"a": `,
+			},
+		},
+		{
+			name:    "include below an encoded value",
+			include: []string{"policy.Version"},
+			expected: map[string]string{
+				"policy.Version": "2012-10-17",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.ExpandEncoded = true
+			f.ExpandEncodedKeys = tt.keys
+			f.Include = tt.include
+
+			result, err := f.FlattenString(yamlStr)
+			if err != nil {
+				t.Fatalf("FlattenString() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("Values = %v, want %v", result.Values, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenExpandEncodedTypesAndLocations(t *testing.T) {
+	f := New()
+	f.ExpandEncoded = true
+
+	result, err := f.FlattenString("config: '{\"port\": 8080, \"debug\": true}'\n")
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+
+	if result.Types["config.port"] != ValueTypeNumber || result.Types["config.debug"] != ValueTypeBool {
+		t.Errorf("Types = %v, want number and bool", result.Types)
+	}
+	if !reflect.DeepEqual(result.Keys, []string{"config.port", "config.debug"}) {
+		t.Errorf("Keys = %v, want source order", result.Keys)
+	}
+	expected := Location{Line: 1, Column: 9}
+	if result.Locations["config.port"] != expected || result.Locations["config.debug"] != expected {
+		t.Errorf("Locations = %v, want the position of the string", result.Locations)
+	}
+
+	json, err := f.FlattenJSON(`{"config": "{\"port\": 8080}"}`)
+	if err != nil {
+		t.Fatalf("FlattenJSON() error = %v", err)
+	}
+	if json.Values["config.port"] != "8080" || len(json.Locations) != 0 {
+		t.Errorf("FlattenJSON() = %v with locations %v", json.Values, json.Locations)
+	}
+}
+
+func TestFlattenExpandEncodedLimits(t *testing.T) {
+	f := New()
+	f.ExpandEncoded = true
+	f.MaxNestingDepth = 2
+
+	_, err := f.FlattenString("a:\n  b: '{\"c\": {\"d\": 1}}'\n")
+	assertErrorType(t, err, ErrTypeDepthLimit)
+
+	f = New()
+	f.ExpandEncoded = true
+	f.RejectAliases = true
+	_, err = f.FlattenString("a: |\n  x: &x 1\n  y: *x\n")
+	assertErrorType(t, err, ErrTypeAlias)
+
+	f = New()
+	f.ExpandEncoded = true
+	f.ExpandEncodedKeys = []string{"regex:("}
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+
+	// without ExpandEncoded strings are never decoded
+	result, err := New().FlattenString("policy: '{\"a\": 1}'\n")
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+	if result.Values["policy"] != `{"a": 1}` {
+		t.Errorf("Values = %v", result.Values)
+	}
+}
@@ -2,6 +2,7 @@
 package flattener
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -13,12 +14,24 @@ const (
 	ErrTypeValidation ErrorType = "validation"
 	// ErrTypeParsing indicates YAML parsing failures
 	ErrTypeParsing ErrorType = "parsing"
+	// ErrTypeJSONParsing indicates JSON parsing failures
+	ErrTypeJSONParsing ErrorType = "json_parsing"
+	// ErrTypeTOMLParsing indicates TOML parsing failures
+	ErrTypeTOMLParsing ErrorType = "toml_parsing"
+	// ErrTypeINIParsing indicates INI parsing failures
+	ErrTypeINIParsing ErrorType = "ini_parsing"
+	// ErrTypePropertiesParsing indicates .properties parsing failures
+	ErrTypePropertiesParsing ErrorType = "properties_parsing"
 	// ErrTypeDepthLimit indicates maximum nesting depth exceeded
 	ErrTypeDepthLimit ErrorType = "depth_limit"
 	// ErrTypeSizeLimit indicates size limit exceeded
 	ErrTypeSizeLimit ErrorType = "size_limit"
+	// ErrTypeAlias indicates a YAML alias was refused or expanded too often
+	ErrTypeAlias ErrorType = "alias"
 	// ErrTypeTimeout indicates operation timed out
 	ErrTypeTimeout ErrorType = "timeout"
+	// ErrTypeCancelled indicates the operation was cancelled by its context
+	ErrTypeCancelled ErrorType = "cancelled"
 	// ErrTypePathSecurity indicates a file path failed security checks
 	ErrTypePathSecurity ErrorType = "path_security"
 	// ErrTypeFileAccess indicates a file could not be accessed
@@ -30,14 +43,91 @@ type Error struct {
 	Type    ErrorType
 	Message string
 	Err     error
+
+	// Path is the flattened key at which the error occurred, if known
+	Path string
+	// File is the file being flattened, if known
+	File string
+	// Line and Column locate the error in the source, starting at 1. They are zero
+	// when the position is unknown.
+	Line   int
+	Column int
+
+	// Limit names the Flattener setting whose limit was exceeded, e.g. "MaxResultSize",
+	// for depth, size, alias and timeout errors
+	Limit string
+	// Rule names the path rule that rejected a file for security errors, one of the
+	// PathRule constants
+	Rule string
 }
 
 // Error implements the error interface
 func (e *Error) Error() string {
+	message := e.Message
+	if e.Path != "" {
+		message += " at " + e.Path
+	}
+	if position := e.position(); position != "" {
+		message += " (" + position + ")"
+	}
 	if e.Err != nil {
-		return fmt.Sprintf("%s error: %s: %v", e.Type, e.Message, e.Err)
+		return fmt.Sprintf("%s error: %s: %v", e.Type, message, e.Err)
+	}
+	return fmt.Sprintf("%s error: %s", e.Type, message)
+}
+
+// position renders File, Line and Column as file:line:column, or as "line L, column C"
+// when the file is unknown
+func (e *Error) position() string {
+	switch {
+	case e.Line == 0:
+		return e.File
+	case e.File != "" && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	case e.File != "":
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	case e.Column > 0:
+		return fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	default:
+		return fmt.Sprintf("line %d", e.Line)
+	}
+}
+
+// at sets the key path of the error unless it is already set
+func (e *Error) at(path string) *Error {
+	if e.Path == "" {
+		e.Path = path
+	}
+	return e
+}
+
+// atLine sets the source position of the error unless it is already set
+func (e *Error) atLine(line, column int) *Error {
+	if e.Line == 0 {
+		e.Line, e.Column = line, column
+	}
+	return e
+}
+
+// limitedBy records the Flattener setting whose limit e reports
+func (e *Error) limitedBy(setting string) *Error {
+	e.Limit = setting
+	return e
+}
+
+// violates records the path rule that e reports
+func (e *Error) violates(rule string) *Error {
+	e.Rule = rule
+	return e
+}
+
+// inFile sets the file of an error that has a path or position, unless it is already set
+func inFile(err error, file string) error {
+	var fe *Error
+	if file != "" && errors.As(err, &fe) && fe.File == "" && (fe.Path != "" || fe.Line > 0) {
+		fe.File = file
 	}
-	return fmt.Sprintf("%s error: %s", e.Type, e.Message)
+	return err
 }
 
 // Unwrap returns the underlying error
@@ -72,12 +162,49 @@ func ParsingError(message string, err error) *Error {
 	}
 }
 
+// JSONParsingError creates a JSON parsing error
+func JSONParsingError(message string, err error) *Error {
+	return &Error{
+		Type:    ErrTypeJSONParsing,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// TOMLParsingError creates a TOML parsing error
+func TOMLParsingError(message string, err error) *Error {
+	return &Error{
+		Type:    ErrTypeTOMLParsing,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// INIParsingError creates an INI parsing error
+func INIParsingError(message string, err error) *Error {
+	return &Error{
+		Type:    ErrTypeINIParsing,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// PropertiesParsingError creates a .properties parsing error
+func PropertiesParsingError(message string, err error) *Error {
+	return &Error{
+		Type:    ErrTypePropertiesParsing,
+		Message: message,
+		Err:     err,
+	}
+}
+
 // DepthLimitError creates a depth limit error
 func DepthLimitError(depth int) *Error {
 	return &Error{
 		Type:    ErrTypeDepthLimit,
 		Message: fmt.Sprintf("maximum nesting depth of %d exceeded", depth),
 		Err:     nil,
+		Limit:   "MaxNestingDepth",
 	}
 }
 
@@ -90,12 +217,38 @@ func SizeLimitError(size int, limitType string) *Error {
 	}
 }
 
+// AliasLimitError creates an error for exceeding the alias expansion budget
+func AliasLimitError(limit int) *Error {
+	return &Error{
+		Type:    ErrTypeAlias,
+		Message: fmt.Sprintf("maximum of %d alias expansions exceeded", limit),
+		Limit:   "MaxAliasExpansions",
+	}
+}
+
+// AliasRejectedError creates an error for an alias found while aliases are refused
+func AliasRejectedError(alias string) *Error {
+	return &Error{
+		Type:    ErrTypeAlias,
+		Message: fmt.Sprintf("alias *%s is not allowed", alias),
+	}
+}
+
 // TimeoutError creates a timeout error
 func TimeoutError(operation string) *Error {
 	return &Error{
 		Type:    ErrTypeTimeout,
 		Message: fmt.Sprintf("%s timed out, content may be too complex", operation),
 		Err:     nil,
+		Limit:   "Timeout",
+	}
+}
+
+// CancelledError creates an error for an operation whose context was cancelled
+func CancelledError(operation string) *Error {
+	return &Error{
+		Type:    ErrTypeCancelled,
+		Message: fmt.Sprintf("%s was cancelled", operation),
 	}
 }
 
@@ -0,0 +1,188 @@
+package flattener
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestErrorPosition(t *testing.T) {
+	deep := New()
+	deep.MaxNestingDepth = 2
+
+	small := New()
+	small.MaxResultSize = 2
+
+	snake := New()
+	snake.KeyTransforms = []KeyTransform{KeyTransformSnake}
+
+	tests := []struct {
+		name    string
+		flatten func() error
+		path    string
+		line    int
+		column  int
+		message string
+	}{
+		{
+			name: "Depth limit",
+			flatten: func() error {
+				_, err := deep.FlattenString("a:\n  b:\n    c:\n      - 1\n")
+				return err
+			},
+			path:    "a.b.c",
+			line:    4,
+			column:  7,
+			message: "maximum nesting depth of 2 exceeded at a.b.c (line 4, column 7)",
+		},
+		{
+			name: "Size limit",
+			flatten: func() error {
+				_, err := small.FlattenString("a: 1\nb: 2\nc: 3\n")
+				return err
+			},
+			path:    "c",
+			line:    3,
+			column:  4,
+			message: "maximum result size of 2 exceeded at c (line 3, column 4)",
+		},
+		{
+			name: "Duplicate key",
+			flatten: func() error {
+				_, err := New().FlattenString("a:\n  b: 1\n  b: 2\n")
+				return err
+			},
+			path:   "a.b",
+			line:   3,
+			column: 3,
+		},
+		{
+			name: "Invalid typed value",
+			flatten: func() error {
+				_, err := New().FlattenString("a: !!int x\n")
+				return err
+			},
+			path:   "a",
+			line:   1,
+			column: 4,
+		},
+		{
+			name: "YAML syntax in later document",
+			flatten: func() error {
+				_, err := New().FlattenDocumentsPrefixed("a: 1\n---\nb: : c\n", "")
+				return err
+			},
+			line: 3,
+		},
+		{
+			name: "JSON syntax",
+			flatten: func() error {
+				_, err := New().FlattenJSON("{\n  \"a\": }")
+				return err
+			},
+			line:    2,
+			column:  8,
+			message: "invalid JSON (line 2, column 8)",
+		},
+		{
+			name: "TOML syntax",
+			flatten: func() error {
+				_, err := New().FlattenFormat("a = 1\nb = \n", FormatTOML)
+				return err
+			},
+			line:   2,
+			column: 5,
+		},
+		{
+			name: "INI syntax",
+			flatten: func() error {
+				_, err := New().FlattenFormat("[server]\nport\n", FormatINI)
+				return err
+			},
+			line:    2,
+			message: "expected key = value (line 2)",
+		},
+		{
+			name: "Properties escape",
+			flatten: func() error {
+				_, err := New().FlattenFormat("a=1\nb=\\u12\n", FormatProperties)
+				return err
+			},
+			line: 2,
+		},
+		{
+			name: "Key transform collision",
+			flatten: func() error {
+				_, err := snake.FlattenString("db:\n  host: a\ndb_host: b\n")
+				return err
+			},
+			path:   "db_host",
+			line:   3,
+			column: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flatten()
+			fe, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("expected *Error, got %T: %v", err, err)
+			}
+			if fe.Path != tt.path || fe.Line != tt.line || fe.Column != tt.column {
+				t.Errorf("position = %q %d:%d, want %q %d:%d (%v)", fe.Path, fe.Line, fe.Column, tt.path, tt.line, tt.column, err)
+			}
+			if tt.message != "" && !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Error() = %q, want it to contain %q", err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestErrorPositionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("a:\n  b: 1\n  b: 2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := New().FlattenFile(path)
+	fe, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %T: %v", err, err)
+	}
+	if fe.File != path {
+		t.Errorf("File = %q, want %q", fe.File, path)
+	}
+	if want := "at a.b (" + path + ":3:3)"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Error() = %q, want it to contain %q", err.Error(), want)
+	}
+
+	jsonPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(jsonPath, []byte("{\"a\": }"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = New().FlattenFileFormat(jsonPath, "")
+	if want := "(" + jsonPath + ":1:7)"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Error() = %v, want it to contain %q", err, want)
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		expected string
+	}{
+		{err: DepthLimitError(3), expected: "depth_limit error: maximum nesting depth of 3 exceeded"},
+		{err: &Error{Type: ErrTypeDepthLimit, Message: "m", Path: "a.b[3]", File: "c.yaml", Line: 412, Column: 7}, expected: "depth_limit error: m at a.b[3] (c.yaml:412:7)"},
+		{err: &Error{Type: ErrTypeParsing, Message: "m", File: "c.yaml", Line: 2}, expected: "parsing error: m (c.yaml:2)"},
+		{err: &Error{Type: ErrTypeParsing, Message: "m", Line: 2, Column: 5}, expected: "parsing error: m (line 2, column 5)"},
+		{err: &Error{Type: ErrTypeParsing, Message: "m", Path: "k"}, expected: "parsing error: m at k"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.expected {
+			t.Errorf("Error() = %q, want %q", got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RegexPatternPrefix marks an Include or Exclude pattern as a regular expression instead of a glob
+const RegexPatternPrefix = "regex:"
+
+// keyFilter decides which flattened keys are kept, based on the Include and Exclude patterns
+type keyFilter struct {
+	include []*keyPattern
+	exclude []*keyPattern
+}
+
+// keyPattern is a compiled Include or Exclude pattern
+type keyPattern struct {
+	regex  *regexp.Regexp
+	glob   []globToken
+	prefix string
+}
+
+// globTokenKind identifies the parts of a glob pattern
+type globTokenKind int
+
+const (
+	globLiteral   globTokenKind = iota
+	globStar                    // * matches within a single key segment
+	globAny                     // ** matches anything, including separators
+	globAnyPrefix               // **<sep> matches nothing or anything ending in the separator
+	globAnySuffix               // <sep>** at the end matches nothing or anything starting with the separator
+	globChar                    // ? matches a single character
+)
+
+type globToken struct {
+	kind    globTokenKind
+	literal string
+}
+
+// compileFilter compiles the Include and Exclude patterns
+func (f *Flattener) compileFilter() (*keyFilter, error) {
+	include, err := compilePatterns(f.Include, "include", f.Separator)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(f.Exclude, "exclude", f.Separator)
+	if err != nil {
+		return nil, err
+	}
+	return &keyFilter{include: include, exclude: exclude}, nil
+}
+
+func compilePatterns(patterns []string, kind, separator string) ([]*keyPattern, error) {
+	compiled := make([]*keyPattern, 0, len(patterns))
+	for _, p := range patterns {
+		if expr, ok := strings.CutPrefix(p, RegexPatternPrefix); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, ValidationError(fmt.Sprintf("invalid %s pattern %q", kind, p), err)
+			}
+			compiled = append(compiled, &keyPattern{regex: re})
+			continue
+		}
+		if p == "" {
+			return nil, ValidationError(fmt.Sprintf("%s pattern cannot be empty", kind), nil)
+		}
+		compiled = append(compiled, compileGlob(p, separator))
+	}
+	return compiled, nil
+}
+
+// compileGlob splits a glob pattern into tokens. The literal text before the first
+// wildcard is kept as prefix so that subtrees which cannot match are not walked.
+func compileGlob(pattern, separator string) *keyPattern {
+	var tokens []globToken
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, globToken{kind: globLiteral, literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); {
+		rest := pattern[i:]
+		switch {
+		case strings.HasPrefix(rest, "**"+separator):
+			flush()
+			tokens = append(tokens, globToken{kind: globAnyPrefix})
+			i += 2 + len(separator)
+		case rest == separator+"**":
+			flush()
+			tokens = append(tokens, globToken{kind: globAnySuffix})
+			i += len(rest)
+		case strings.HasPrefix(rest, "**"):
+			flush()
+			tokens = append(tokens, globToken{kind: globAny})
+			i += 2
+		case rest[0] == '*':
+			flush()
+			tokens = append(tokens, globToken{kind: globStar})
+			i++
+		case rest[0] == '?':
+			flush()
+			tokens = append(tokens, globToken{kind: globChar})
+			i++
+		default:
+			literal.WriteByte(rest[0])
+			i++
+		}
+	}
+	flush()
+
+	p := &keyPattern{glob: tokens}
+	if len(tokens) > 0 && tokens[0].kind == globLiteral {
+		p.prefix = tokens[0].literal
+	}
+	return p
+}
+
+// match reports whether the pattern matches the whole key (globs) or any part of it (regexes)
+func (p *keyPattern) match(key, separator string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(key)
+	}
+	memo := make(map[[2]int]bool)
+	return matchGlob(p.glob, key, separator, 0, 0, memo)
+}
+
+// mayMatchBelow reports whether the pattern could match a key starting with prefix
+func (p *keyPattern) mayMatchBelow(prefix string) bool {
+	if p.regex != nil {
+		return true
+	}
+	return strings.HasPrefix(p.prefix, prefix) || strings.HasPrefix(prefix, p.prefix)
+}
+
+func matchGlob(tokens []globToken, key, sep string, ti, ki int, memo map[[2]int]bool) bool {
+	if ti == len(tokens) {
+		return ki == len(key)
+	}
+	state := [2]int{ti, ki}
+	if matched, ok := memo[state]; ok {
+		return matched
+	}
+
+	matched := false
+	t := tokens[ti]
+	rest := key[ki:]
+	switch t.kind {
+	case globLiteral:
+		matched = strings.HasPrefix(rest, t.literal) && matchGlob(tokens, key, sep, ti+1, ki+len(t.literal), memo)
+	case globChar:
+		matched = ki < len(key) && matchGlob(tokens, key, sep, ti+1, ki+1, memo)
+	case globStar:
+		limit := len(key)
+		if i := strings.Index(rest, sep); i >= 0 {
+			limit = ki + i
+		}
+		for end := ki; end <= limit && !matched; end++ {
+			matched = matchGlob(tokens, key, sep, ti+1, end, memo)
+		}
+	case globAny:
+		for end := ki; end <= len(key) && !matched; end++ {
+			matched = matchGlob(tokens, key, sep, ti+1, end, memo)
+		}
+	case globAnyPrefix:
+		matched = matchGlob(tokens, key, sep, ti+1, ki, memo)
+		for end := ki; end < len(key) && !matched; end++ {
+			if strings.HasPrefix(key[end:], sep) {
+				matched = matchGlob(tokens, key, sep, ti+1, end+len(sep), memo)
+			}
+		}
+	case globAnySuffix:
+		matched = ki == len(key) || strings.HasPrefix(rest, sep)
+	}
+
+	memo[state] = matched
+	return matched
+}
+
+// includeAll reports whether every key is included unless excluded
+func (kf *keyFilter) includeAll() bool {
+	return len(kf.include) == 0
+}
+
+// includes reports whether key matches an Include pattern
+func (kf *keyFilter) includes(key, separator string) bool {
+	for _, p := range kf.include {
+		if p.match(key, separator) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludes reports whether key matches an Exclude pattern
+func (kf *keyFilter) excludes(key, separator string) bool {
+	for _, p := range kf.exclude {
+		if p.match(key, separator) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayInclude reports whether any key below prefix could match an Include pattern
+func (kf *keyFilter) mayInclude(prefix string) bool {
+	for _, p := range kf.include {
+		if p.mayMatchBelow(prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package flattener
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenIncludeExclude(t *testing.T) {
+	yamlStr := `
+password: top
+database:
+  primary:
+    host: db1
+    password: secret1
+  replicas:
+    - host: r1
+      password: secret2
+    - host: r2
+cache:
+  host: redis
+`
+
+	tests := []struct {
+		name      string
+		separator string
+		include   []string
+		exclude   []string
+		expected  map[string]string
+	}{
+		{
+			name:    "Single segment wildcard",
+			include: []string{"database.*.host"},
+			expected: map[string]string{
+				"database.primary.host":     "db1",
+				"database.replicas[0].host": "r1",
+				"database.replicas[1].host": "r2",
+			},
+		},
+		{
+			name:    "Wildcard does not cross separators",
+			include: []string{"*.host"},
+			expected: map[string]string{
+				"cache.host": "redis",
+			},
+		},
+		{
+			name:    "Wildcard inside array segment",
+			include: []string{"database.replicas[*].host"},
+			expected: map[string]string{
+				"database.replicas[0].host": "r1",
+				"database.replicas[1].host": "r2",
+			},
+		},
+		{
+			name:    "Any depth exclude",
+			exclude: []string{"**.password"},
+			expected: map[string]string{
+				"database.primary.host":     "db1",
+				"database.replicas[0].host": "r1",
+				"database.replicas[1].host": "r2",
+				"cache.host":                "redis",
+			},
+		},
+		{
+			name:    "Include subtree",
+			include: []string{"cache"},
+			expected: map[string]string{
+				"cache.host": "redis",
+			},
+		},
+		{
+			name:    "Include subtree with trailing any",
+			include: []string{"database.primary.**"},
+			expected: map[string]string{
+				"database.primary.host":     "db1",
+				"database.primary.password": "secret1",
+			},
+		},
+		{
+			name:    "Exclude wins over include",
+			include: []string{"database"},
+			exclude: []string{"database.replicas"},
+			expected: map[string]string{
+				"database.primary.host":     "db1",
+				"database.primary.password": "secret1",
+			},
+		},
+		{
+			name:    "Regex include",
+			include: []string{`regex:host$`},
+			expected: map[string]string{
+				"database.primary.host":     "db1",
+				"database.replicas[0].host": "r1",
+				"database.replicas[1].host": "r2",
+				"cache.host":                "redis",
+			},
+		},
+		{
+			name:    "Regex exclude",
+			exclude: []string{`regex:^database\.replicas\[\d+\]`, "regex:password"},
+			expected: map[string]string{
+				"database.primary.host": "db1",
+				"cache.host":            "redis",
+			},
+		},
+		{
+			name:      "Multi-character separator",
+			separator: "__",
+			include:   []string{"database__*__host", "cache__?ost"},
+			expected: map[string]string{
+				"database__primary__host":     "db1",
+				"database__replicas[0]__host": "r1",
+				"database__replicas[1]__host": "r2",
+				"cache__host":                 "redis",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			if tt.separator != "" {
+				f.Separator = tt.separator
+			}
+			f.Include = tt.include
+			f.Exclude = tt.exclude
+
+			result, err := f.FlattenYAMLString(yamlStr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("FlattenYAMLString() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenExcludedSubtreeNotWalked(t *testing.T) {
+	var builder strings.Builder
+	builder.WriteString("keep: value\nhuge:\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&builder, "  key%d: value%d\n", i, i)
+	}
+
+	t.Run("exclude", func(t *testing.T) {
+		f := New()
+		f.MaxResultSize = 10
+		f.Exclude = []string{"huge"}
+
+		result, err := f.FlattenYAMLString(builder.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result, map[string]string{"keep": "value"}) {
+			t.Errorf("unexpected result %v", result)
+		}
+	})
+
+	t.Run("include", func(t *testing.T) {
+		f := New()
+		f.MaxResultSize = 10
+		f.Include = []string{"keep"}
+
+		result, err := f.FlattenYAMLString(builder.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result, map[string]string{"keep": "value"}) {
+			t.Errorf("unexpected result %v", result)
+		}
+	})
+}
+
+func TestFilterPatternValidation(t *testing.T) {
+	f := New()
+	f.Include = []string{"regex:("}
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+
+	f = New()
+	f.Exclude = []string{""}
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+}
@@ -2,9 +2,14 @@
 package flattener
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -21,102 +26,596 @@ const (
 
 	// MaxResultSize defines the maximum number of key-value pairs in the result
 	MaxResultSize = 100000
+
+	// DefaultTimeout bounds the time spent parsing and flattening one input
+	DefaultTimeout = 5 * time.Second
+
+	// MaxAliasExpansions defines the maximum number of YAML nodes and merged keys
+	// walked through aliases, to stop "billion laughs" documents early
+	MaxAliasExpansions = 100000
+
+	// MaxKeyLength defines the length in bytes at which a single map key is truncated
+	MaxKeyLength = 1000
+
+	// Upper bounds accepted by Validate for the configurable limits, so that a typo
+	// cannot disable a limit altogether
+	MaxNestingDepthCeiling    = 10000
+	MaxResultSizeCeiling      = 10000000
+	MaxYAMLSizeCeiling        = 1024 * 1024 * 1024
+	MaxAliasExpansionsCeiling = 100000000
+	MaxKeyLengthCeiling       = 65536
+	TimeoutCeiling            = time.Hour
+
+	// DefaultSeparator is the string placed between nested object keys
+	DefaultSeparator = "."
+
+	// IndexPlaceholder is replaced with the array index in an ArrayTemplate
+	IndexPlaceholder = "{index}"
 )
 
+// ArrayStyle controls how array indices are rendered in flattened keys
+type ArrayStyle string
+
+const (
+	// ArrayStyleBrackets renders indices in brackets, e.g. items[0]
+	ArrayStyleBrackets ArrayStyle = "brackets"
+	// ArrayStyleDotted renders indices as a key segment joined by the separator, e.g. items.0
+	ArrayStyleDotted ArrayStyle = "dotted"
+	// ArrayStyleTemplate renders indices using ArrayTemplate, e.g. "__{index}" gives items__0
+	ArrayStyleTemplate ArrayStyle = "template"
+)
+
+// EmptyCollections controls what empty objects and arrays are flattened to
+type EmptyCollections string
+
+const (
+	// EmptyCollectionsOmit leaves empty objects and arrays out of the result
+	EmptyCollectionsOmit EmptyCollections = "omit"
+	// EmptyCollectionsLiteral flattens empty objects to "{}" and empty arrays to "[]"
+	EmptyCollectionsLiteral EmptyCollections = "literal"
+	// EmptyCollectionsEmptyString flattens empty objects and arrays to ""
+	EmptyCollectionsEmptyString EmptyCollections = "empty_string"
+)
+
+// ValueType identifies the type a flattened value had before it was converted to a string
+type ValueType string
+
+const (
+	// ValueTypeString marks string values and any scalar without a more specific type
+	ValueTypeString ValueType = "string"
+	// ValueTypeNumber marks integer and floating point values
+	ValueTypeNumber ValueType = "number"
+	// ValueTypeBool marks boolean values
+	ValueTypeBool ValueType = "bool"
+	// ValueTypeNull marks null values, which are flattened to an empty string
+	ValueTypeNull ValueType = "null"
+	// ValueTypeObject marks empty objects kept by EmptyCollections
+	ValueTypeObject ValueType = "object"
+	// ValueTypeArray marks empty arrays kept by EmptyCollections
+	ValueTypeArray ValueType = "array"
+)
+
+// Result holds the flattened values of a document together with the type of each value
+type Result struct {
+	Values map[string]string
+	Types  map[string]ValueType
+	// Keys lists the keys of Values in document order
+	Keys []string
+	// Locations holds the source position of every value read from YAML. Other input
+	// formats do not record locations.
+	Locations map[string]Location
+	// Anchors maps every key whose value was copied through a YAML alias or merge key
+	// to the name of the anchor it was copied from
+	Anchors map[string]string
+	// Sources maps every key of a merged result to the name of the layer its value
+	// came from. It is empty for results of a single document.
+	Sources map[string]string
+	// Warnings lists the key collisions resolved under KeyCollisionsWarn
+	Warnings []string
+}
+
+func newResult() *Result {
+	return &Result{
+		Values:    make(map[string]string),
+		Types:     make(map[string]ValueType),
+		Locations: make(map[string]Location),
+		Anchors:   make(map[string]string),
+		Sources:   make(map[string]string),
+	}
+}
+
+func (r *Result) set(key, value string, valueType ValueType) {
+	if _, ok := r.Values[key]; !ok {
+		r.Keys = append(r.Keys, key)
+	}
+	r.Values[key] = value
+	r.Types[key] = valueType
+}
+
 // Flattener provides functionality to flatten nested YAML structures
 type Flattener struct {
 	MaxNestingDepth int
 	MaxResultSize   int
 	MaxYAMLSize     int
+	// MaxKeyLength truncates longer map keys, counted in bytes per key segment
+	MaxKeyLength int
+	// MaxAliasExpansions limits the number of YAML nodes and merged keys walked
+	// through aliases in one flatten call
+	MaxAliasExpansions int
+	// RejectAliases fails on any YAML alias, including aliases in merge keys, for
+	// untrusted input. Anchors without aliases are accepted.
+	RejectAliases bool
+	// Timeout bounds the time spent parsing and flattening one input, on top of any
+	// deadline of the context passed to the Context methods. Zero disables it.
+	Timeout time.Duration
+
+	// ArrayMerge selects how arrays present in two layers are combined by FlattenLayers
+	// (default replace)
+	ArrayMerge ArrayMerge
+	// ArrayMergeKey names the field that identifies array items for ArrayMergeByKey
+	ArrayMergeKey string
+
+	// Separator is placed between nested object keys (default ".")
+	Separator string
+	// ArrayStyle selects how array indices are appended to keys (default brackets)
+	ArrayStyle ArrayStyle
+	// ArrayTemplate is appended to the key for each array element when ArrayStyle
+	// is ArrayStyleTemplate. It must contain IndexPlaceholder.
+	ArrayTemplate string
+	// EmptyCollections selects whether empty objects and arrays are kept in the result
+	// and as which value (default omit). Unflatten turns the values of
+	// EmptyCollectionsLiteral back into empty objects and arrays.
+	EmptyCollections EmptyCollections
+	// KeyCollisions selects what happens when two values flatten to the same key
+	// (default error)
+	KeyCollisions KeyCollisions
+	// KeyEscaping selects how object keys containing the separator or an array marker
+	// are escaped (default none). ParseKey and Unflatten undo the escapes.
+	KeyEscaping KeyEscaping
+	// NonStringKeys selects how int, float, bool, null and timestamp map keys become
+	// key segments (default canonical)
+	NonStringKeys NonStringKeys
+	// ScalarFormat selects whether YAML scalars are written in canonical form or as in
+	// the source (default canonical)
+	ScalarFormat ScalarFormat
+	// YAMLProfile selects the schema that types plain YAML scalars (default resolves
+	// like gopkg.in/yaml.v3)
+	YAMLProfile YAMLProfile
+
+	// Include limits the result to keys matching at least one pattern. Patterns are
+	// globs over flattened keys, where * matches within one key segment and ** matches
+	// any number of segments, or regular expressions prefixed with RegexPatternPrefix.
+	// A pattern matching an object or array includes everything below it.
+	Include []string
+	// Exclude removes keys matching any pattern, using the same syntax as Include.
+	// Excluded objects and arrays are not walked at all.
+	Exclude []string
+
+	// KeyTransforms is a pipeline of case transforms applied in order to every key
+	// after filtering, e.g. snake then upper gives DATABASE_PRIMARY_HOST.
+	KeyTransforms []KeyTransform
+	// KeyIllegalChars is a regular expression matching characters to replace with
+	// KeyReplacement after the KeyTransforms have run, e.g. "[^A-Za-z0-9_]".
+	KeyIllegalChars string
+	// KeyReplacement replaces every match of KeyIllegalChars. It may be empty to
+	// remove the characters.
+	KeyReplacement string
+	// KeyPrefix is prepended to every key as the last step of the pipeline.
+	KeyPrefix string
+
+	// ExpandEncoded flattens string values holding a JSON object or array, or a
+	// multi-line YAML mapping or sequence, into the key space below their key, e.g. a
+	// policy string '{"Version": "2012-10-17"}' yields policy.Version. Strings that do
+	// not parse are kept as they are.
+	ExpandEncoded bool
+	// ExpandEncodedKeys limits ExpandEncoded to the keys matching at least one pattern,
+	// using the syntax of Include. Empty expands every string value.
+	ExpandEncodedKeys []string
+
+	// AllowedBaseDirs restricts the files that can be read to these directories and
+	// their subdirectories. Empty allows any path without directory traversal.
+	AllowedBaseDirs []string
+	// SymlinkPolicy selects which symbolic links file paths may go through (default
+	// follow, which keeps links inside AllowedBaseDirs when it is set)
+	SymlinkPolicy SymlinkPolicy
+
+	// SourceFile is recorded as the file of every Location in the result. FlattenFile
+	// and FlattenFileFormat set it to the path they read unless it is already set.
+	SourceFile string
+}
+
+// walker holds the state of a single flatten call
+type walker struct {
+	*Flattener
+	ctx    context.Context
+	result *Result
+	filter *keyFilter
+	keys   *keyTransformer
+	// encoded holds the compiled ExpandEncodedKeys patterns
+	encoded []*keyPattern
+	// anchors holds the anchored nodes currently being walked, to detect anchors
+	// that contain themselves
+	anchors map[*yaml.Node]bool
+	// alias is the name of the anchor the walk is currently copying from, if any
+	alias string
+	// expansions counts the nodes and merged keys walked through aliases
+	expansions int
+	// visited counts the values walked, to check the context periodically
+	visited int
+	// layers names the layers of a merged walk, and origin records the layer every
+	// node of a merged document was read from
+	layers []string
+	origin map[*yaml.Node]int
+}
+
+// contextCheckInterval is the number of values walked between context checks
+const contextCheckInterval = 256
+
+// newWalker validates the settings and prepares the state for a flatten call
+func (f *Flattener) newWalker(ctx context.Context) (*walker, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter, err := f.compileFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := f.compileKeyTransformer()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := f.compileEncodedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return &walker{
+		Flattener: f,
+		ctx:       ctx,
+		result:    newResult(),
+		filter:    filter,
+		keys:      keys,
+		encoded:   encoded,
+		anchors:   make(map[*yaml.Node]bool),
+	}, nil
+}
+
+// finish applies the key transforms to the collected result
+func (w *walker) finish() (*Result, error) {
+	if w.keys == nil {
+		return w.result, nil
+	}
+	return w.transformKeys()
 }
 
 // New creates a Flattener instance with default settings
 func New() *Flattener {
 	return &Flattener{
-		MaxNestingDepth: MaxNestingDepth,
-		MaxResultSize:   MaxResultSize,
-		MaxYAMLSize:     MaxYAMLSize,
+		MaxNestingDepth:    MaxNestingDepth,
+		MaxResultSize:      MaxResultSize,
+		MaxYAMLSize:        MaxYAMLSize,
+		MaxAliasExpansions: MaxAliasExpansions,
+		MaxKeyLength:       MaxKeyLength,
+		Timeout:            DefaultTimeout,
+		Separator:          DefaultSeparator,
+		ArrayStyle:         ArrayStyleBrackets,
+		ArrayMerge:         ArrayMergeReplace,
+		EmptyCollections:   EmptyCollectionsOmit,
+		KeyCollisions:      KeyCollisionsError,
+		KeyEscaping:        KeyEscapingNone,
+		NonStringKeys:      NonStringKeysCanonical,
+		ScalarFormat:       ScalarFormatCanonical,
+		YAMLProfile:        YAMLProfileDefault,
+		SymlinkPolicy:      SymlinkFollow,
 	}
 }
 
+// Validate checks that the Flattener settings are usable
+func (f *Flattener) Validate() error {
+	limits := []struct {
+		name       string
+		value, min int64
+		max        int64
+	}{
+		{"MaxNestingDepth", int64(f.MaxNestingDepth), 1, MaxNestingDepthCeiling},
+		{"MaxResultSize", int64(f.MaxResultSize), 1, MaxResultSizeCeiling},
+		{"MaxYAMLSize", int64(f.MaxYAMLSize), 1, MaxYAMLSizeCeiling},
+		{"MaxAliasExpansions", int64(f.MaxAliasExpansions), 0, MaxAliasExpansionsCeiling},
+		{"MaxKeyLength", int64(f.MaxKeyLength), 1, MaxKeyLengthCeiling},
+	}
+	for _, l := range limits {
+		if l.value < l.min || l.value > l.max {
+			return ValidationError(fmt.Sprintf("%s must be between %d and %d, got %d", l.name, l.min, l.max, l.value), nil)
+		}
+	}
+	if f.Timeout < 0 || f.Timeout > TimeoutCeiling {
+		return ValidationError(fmt.Sprintf("Timeout must be between 0 and %s, got %s", TimeoutCeiling, f.Timeout), nil)
+	}
+
+	if f.Separator == "" {
+		return ValidationError("separator cannot be empty", nil)
+	}
+
+	switch f.ArrayStyle {
+	case ArrayStyleBrackets, ArrayStyleDotted:
+	case ArrayStyleTemplate:
+		if !strings.Contains(f.ArrayTemplate, IndexPlaceholder) {
+			return ValidationError(fmt.Sprintf("array template %q must contain %s", f.ArrayTemplate, IndexPlaceholder), nil)
+		}
+	default:
+		return ValidationError(fmt.Sprintf("unsupported array style %q, expected one of: %s, %s, %s",
+			f.ArrayStyle, ArrayStyleBrackets, ArrayStyleDotted, ArrayStyleTemplate), nil)
+	}
+
+	switch f.EmptyCollections {
+	case EmptyCollectionsOmit, EmptyCollectionsLiteral, EmptyCollectionsEmptyString:
+	default:
+		return ValidationError(fmt.Sprintf("unsupported empty collections policy %q, expected one of: %s, %s, %s",
+			f.EmptyCollections, EmptyCollectionsOmit, EmptyCollectionsLiteral, EmptyCollectionsEmptyString), nil)
+	}
+
+	switch f.KeyCollisions {
+	case KeyCollisionsError, KeyCollisionsWarn, KeyCollisionsFirstWins, KeyCollisionsLastWins, KeyCollisionsEscape:
+	default:
+		return ValidationError(fmt.Sprintf("unsupported key collision policy %q, expected one of: %s, %s, %s, %s, %s",
+			f.KeyCollisions, KeyCollisionsError, KeyCollisionsWarn, KeyCollisionsFirstWins, KeyCollisionsLastWins, KeyCollisionsEscape), nil)
+	}
+
+	switch f.KeyEscaping {
+	case KeyEscapingNone, KeyEscapingBackslash, KeyEscapingQuote:
+	default:
+		return ValidationError(fmt.Sprintf("unsupported key escaping %q, expected one of: %s, %s, %s",
+			f.KeyEscaping, KeyEscapingNone, KeyEscapingBackslash, KeyEscapingQuote), nil)
+	}
+
+	switch f.NonStringKeys {
+	case NonStringKeysCanonical, NonStringKeysReject, NonStringKeysSkip:
+	default:
+		return ValidationError(fmt.Sprintf("unsupported non-string key policy %q, expected one of: %s, %s, %s",
+			f.NonStringKeys, NonStringKeysCanonical, NonStringKeysReject, NonStringKeysSkip), nil)
+	}
+
+	switch f.ScalarFormat {
+	case ScalarFormatCanonical, ScalarFormatPreserve:
+	default:
+		return ValidationError(fmt.Sprintf("unsupported scalar format %q, expected one of: %s, %s",
+			f.ScalarFormat, ScalarFormatCanonical, ScalarFormatPreserve), nil)
+	}
+
+	switch f.YAMLProfile {
+	case YAMLProfileDefault, YAMLProfileYAML12, YAMLProfileYAML11, YAMLProfileStrings:
+	default:
+		return ValidationError(fmt.Sprintf("unsupported YAML profile %q, expected one of: %s, %s, %s, %s",
+			f.YAMLProfile, YAMLProfileDefault, YAMLProfileYAML12, YAMLProfileYAML11, YAMLProfileStrings), nil)
+	}
+
+	switch f.ArrayMerge {
+	case ArrayMergeReplace, ArrayMergeAppend, ArrayMergeByIndex:
+	case ArrayMergeByKey:
+		if f.ArrayMergeKey == "" {
+			return ValidationError(fmt.Sprintf("array merge %q requires an array merge key", ArrayMergeByKey), nil)
+		}
+	default:
+		return ValidationError(fmt.Sprintf("unsupported array merge %q, expected one of: %s, %s, %s, %s",
+			f.ArrayMerge, ArrayMergeReplace, ArrayMergeAppend, ArrayMergeByIndex, ArrayMergeByKey), nil)
+	}
+
+	if err := f.validatePathPolicy(); err != nil {
+		return err
+	}
+
+	if _, err := f.compileFilter(); err != nil {
+		return err
+	}
+
+	if _, err := f.compileKeyTransformer(); err != nil {
+		return err
+	}
+
+	if _, err := f.compileEncodedKeys(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // FlattenYAML takes a parsed YAML structure and flattens it into a map with dot notation
 func (f *Flattener) FlattenYAML(yamlData interface{}) (map[string]string, error) {
+	result, err := f.Flatten(yamlData)
+	if err != nil {
+		return nil, err
+	}
+	return result.Values, nil
+}
+
+// Flatten takes a parsed YAML structure and flattens it, keeping the type of every value
+func (f *Flattener) Flatten(yamlData interface{}) (*Result, error) {
+	return f.FlattenContext(context.Background(), yamlData)
+}
+
+// FlattenContext is Flatten with a context that stops the traversal when it is done
+func (f *Flattener) FlattenContext(ctx context.Context, yamlData interface{}) (*Result, error) {
 	if yamlData == nil {
 		return nil, ValidationError("cannot flatten nil YAML data", nil)
 	}
 
-	result := make(map[string]string)
-	if err := f.flattenValueWithDepth(yamlData, "", result, 0); err != nil {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	w, err := f.newWalker(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return result, nil
+	if err := w.flattenValueWithDepth(yamlData, "", 0, w.filter.includeAll()); err != nil {
+		return nil, err
+	}
+
+	return w.finish()
 }
 
-// flattenValueWithDepth recursively flattens a YAML value with the given prefix and tracks depth
-func (f *Flattener) flattenValueWithDepth(value interface{}, prefix string, result map[string]string, depth int) error {
-	if depth > f.MaxNestingDepth {
-		return DepthLimitError(f.MaxNestingDepth)
+// flattenValueWithDepth recursively flattens a value with the given prefix and tracks depth.
+// The value is either a *yaml.Node, which is walked in source order, or a decoded value,
+// whose plain Go maps are walked in sorted key order.
+// included is true when an enclosing key already matched an Include pattern.
+func (w *walker) flattenValueWithDepth(value interface{}, prefix string, depth int, included bool) error {
+	if err := w.checkContext("flattening"); err != nil {
+		return w.locate(err, prefix, value)
 	}
 
-	if len(result) >= f.MaxResultSize {
-		return SizeLimitError(f.MaxResultSize, "result")
+	if depth > w.MaxNestingDepth {
+		return w.locate(DepthLimitError(w.MaxNestingDepth), prefix, value)
+	}
+
+	if len(w.result.Values) >= w.MaxResultSize {
+		return w.locate(SizeLimitError(w.MaxResultSize, "result").limitedBy("MaxResultSize"), prefix, value)
+	}
+
+	if w.filter.excludes(prefix, w.Separator) {
+		return nil
+	}
+	if !included {
+		included = w.filter.includes(prefix, w.Separator)
+		if !included && !w.filter.mayInclude(prefix) {
+			return nil
+		}
 	}
 
 	switch v := value.(type) {
+	case *yaml.Node:
+		return w.flattenNodeWithDepth(v, prefix, depth, included)
+	case *orderedMap:
+		return w.flattenOrderedMapWithDepth(v, prefix, depth+1, included)
 	case map[string]interface{}:
-		return f.flattenMapWithDepth(v, prefix, result, depth+1)
+		return w.flattenMapWithDepth(v, prefix, depth+1, included)
 	case map[interface{}]interface{}:
-		return f.flattenInterfaceMapWithDepth(v, prefix, result, depth+1)
+		return w.flattenInterfaceMapWithDepth(v, prefix, depth+1, included)
 	case []interface{}:
-		return f.flattenArrayWithDepth(v, prefix, result, depth+1)
+		return w.flattenArrayWithDepth(v, prefix, depth+1, included)
+	}
+
+	if expanded, err := w.expandEncoded(value, prefix, depth, included, nil); expanded || err != nil {
+		return err
+	}
+	if included {
+		if _, err := w.setScalar(prefix, value); err != nil {
+			return w.locate(err, prefix, value)
+		}
+	}
+	return nil
+}
+
+// checkContext returns a TimeoutError or CancelledError for operation once the context
+// is done. The context is only checked every contextCheckInterval calls.
+func (w *walker) checkContext(operation string) error {
+	w.visited++
+	if w.visited%contextCheckInterval == 1 && w.ctx.Err() != nil {
+		return contextError(w.ctx, operation)
+	}
+	return nil
+}
+
+// locate adds the key path, the position of value when it is a YAML node and the
+// source file to an error raised while walking
+func (w *walker) locate(err error, path string, value interface{}) error {
+	var fe *Error
+	if !errors.As(err, &fe) {
+		return err
+	}
+	fe.at(path)
+	file := w.SourceFile
+	if node, ok := value.(*yaml.Node); ok {
+		fe.atLine(node.Line, node.Column)
+		file = w.fileOf(node)
+	}
+	if fe.File == "" {
+		fe.File = file
+	}
+	return err
+}
+
+// setScalar stores a decoded scalar value in the result and reports whether it was
+// stored, see store
+func (w *walker) setScalar(key string, value interface{}) (bool, error) {
+	s, valueType := scalarString(value)
+	return w.store(key, s, valueType)
+}
+
+// scalarString returns the flattened form and type of a decoded scalar value
+func scalarString(value interface{}) (string, ValueType) {
+	switch v := value.(type) {
 	case string:
-		result[prefix] = v
+		return v, ValueTypeString
 	case int:
-		result[prefix] = strconv.Itoa(v)
+		return strconv.Itoa(v), ValueTypeNumber
 	case int64:
-		result[prefix] = strconv.FormatInt(v, 10)
+		return strconv.FormatInt(v, 10), ValueTypeNumber
+	case uint64:
+		return strconv.FormatUint(v, 10), ValueTypeNumber
 	case float64:
-		result[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+		return formatFloat(v), ValueTypeNumber
+	case json.Number:
+		return v.String(), ValueTypeNumber
 	case bool:
-		result[prefix] = strconv.FormatBool(v)
+		return strconv.FormatBool(v), ValueTypeBool
+	case time.Time:
+		return v.Format(time.RFC3339Nano), ValueTypeString
 	case nil:
-		result[prefix] = ""
+		return "", ValueTypeNull
 	default:
-		result[prefix] = fmt.Sprintf("%v", v)
+		return fmt.Sprintf("%v", v), ValueTypeString
 	}
+}
 
-	return nil
+// setEmpty stores an empty object or array at key according to EmptyCollections and
+// reports whether it was kept. The root of a document has no key and is never kept.
+func (w *walker) setEmpty(key string, isArray bool) (bool, error) {
+	if key == "" || w.EmptyCollections == EmptyCollectionsOmit {
+		return false, nil
+	}
+	value, valueType := "{}", ValueTypeObject
+	if isArray {
+		value, valueType = "[]", ValueTypeArray
+	}
+	if w.EmptyCollections == EmptyCollectionsEmptyString {
+		value = ""
+	}
+	return w.store(key, value, valueType)
 }
 
-// flattenMapWithDepth flattens a map[string]interface{} with the given prefix and tracks depth
-func (f *Flattener) flattenMapWithDepth(m map[string]interface{}, prefix string, result map[string]string, depth int) error {
-	for k, v := range m {
-		k = sanitizeKey(k)
-		newPrefix := k
-		if prefix != "" {
-			newPrefix = prefix + "." + k
+// flattenOrderedMapWithDepth flattens an orderedMap in key order with the given prefix and tracks depth
+func (w *walker) flattenOrderedMapWithDepth(m *orderedMap, prefix string, depth int, included bool) error {
+	if len(m.keys) == 0 && included {
+		if _, err := w.setEmpty(prefix, false); err != nil {
+			return w.locate(err, prefix, nil)
 		}
-		if err := f.flattenValueWithDepth(v, newPrefix, result, depth); err != nil {
+	}
+	for _, k := range m.keys {
+		if err := w.flattenValueWithDepth(m.values[k], w.joinKey(prefix, w.objectKey(k)), depth, included); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// flattenInterfaceMapWithDepth flattens a map[interface{}]interface{} with the given prefix and tracks depth
-func (f *Flattener) flattenInterfaceMapWithDepth(m map[interface{}]interface{}, prefix string, result map[string]string, depth int) error {
-	for k, v := range m {
-		strKey, ok := k.(string)
-		if !ok {
-			return ParsingError(fmt.Sprintf("non-string key %v in YAML map", k), nil)
-		}
-		strKey = sanitizeKey(strKey)
-		newPrefix := strKey
-		if prefix != "" {
-			newPrefix = prefix + "." + strKey
+// flattenMapWithDepth flattens a map[string]interface{} in sorted key order with the given prefix and tracks depth
+func (w *walker) flattenMapWithDepth(m map[string]interface{}, prefix string, depth int, included bool) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 && included {
+		if _, err := w.setEmpty(prefix, false); err != nil {
+			return w.locate(err, prefix, nil)
 		}
-		if err := f.flattenValueWithDepth(v, newPrefix, result, depth); err != nil {
+	}
+	for _, k := range keys {
+		if err := w.flattenValueWithDepth(m[k], w.joinKey(prefix, w.objectKey(k)), depth, included); err != nil {
 			return err
 		}
 	}
@@ -124,92 +623,172 @@ func (f *Flattener) flattenInterfaceMapWithDepth(m map[interface{}]interface{},
 }
 
 // flattenArrayWithDepth flattens an array with the given prefix and tracks depth
-func (f *Flattener) flattenArrayWithDepth(a []interface{}, prefix string, result map[string]string, depth int) error {
+func (w *walker) flattenArrayWithDepth(a []interface{}, prefix string, depth int, included bool) error {
+	if len(a) == 0 && included {
+		if _, err := w.setEmpty(prefix, true); err != nil {
+			return w.locate(err, prefix, nil)
+		}
+	}
 	for i, v := range a {
-		newPrefix := fmt.Sprintf("%s[%d]", prefix, i)
-		if err := f.flattenValueWithDepth(v, newPrefix, result, depth); err != nil {
+		if err := w.flattenValueWithDepth(v, w.indexKey(prefix, i), depth, included); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// FlattenYAMLString takes a YAML string and flattens it into a map with dot notation
-func (f *Flattener) FlattenYAMLString(yamlContent string) (map[string]string, error) {
-	if yamlContent == "" {
-		return nil, ValidationError("YAML content cannot be empty", nil)
+// joinKey appends an object key to the prefix using the configured separator
+func (f *Flattener) joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
 	}
+	return prefix + f.Separator + key
+}
 
-	if strings.TrimSpace(yamlContent) == "" {
-		return nil, ValidationError("YAML content cannot contain only whitespace", nil)
+// indexKey appends an array index to the prefix using the configured array style
+func (f *Flattener) indexKey(prefix string, index int) string {
+	switch f.ArrayStyle {
+	case ArrayStyleDotted:
+		return f.joinKey(prefix, strconv.Itoa(index))
+	case ArrayStyleTemplate:
+		return prefix + strings.ReplaceAll(f.ArrayTemplate, IndexPlaceholder, strconv.Itoa(index))
+	default:
+		return prefix + "[" + strconv.Itoa(index) + "]"
 	}
+}
 
-	if len(yamlContent) > f.MaxYAMLSize {
-		return nil, SizeLimitError(f.MaxYAMLSize, "YAML content")
-	}
+// FlattenYAMLString takes a YAML string and flattens it into a map with dot notation
+func (f *Flattener) FlattenYAMLString(yamlContent string) (map[string]string, error) {
+	return f.FlattenYAMLStringContext(context.Background(), yamlContent)
+}
 
-	yamlContent = sanitizeYAMLContent(yamlContent)
+// FlattenYAMLStringContext is FlattenYAMLString with a context that stops parsing and
+// flattening when it is done
+func (f *Flattener) FlattenYAMLStringContext(ctx context.Context, yamlContent string) (map[string]string, error) {
+	result, err := f.FlattenStringContext(ctx, yamlContent)
+	if err != nil {
+		return nil, err
+	}
+	return result.Values, nil
+}
 
-	var yamlData interface{}
+// FlattenString takes a YAML string and flattens it, keeping the type of every value
+func (f *Flattener) FlattenString(yamlContent string) (*Result, error) {
+	return f.FlattenStringContext(context.Background(), yamlContent)
+}
 
-	done := make(chan struct{})
-	var err error
+// FlattenStringContext is FlattenString with a context that stops parsing and
+// flattening when it is done
+func (f *Flattener) FlattenStringContext(ctx context.Context, yamlContent string) (*Result, error) {
+	return f.FlattenFormatContext(ctx, yamlContent, FormatYAML)
+}
 
-	go func() {
-		err = yaml.Unmarshal([]byte(yamlContent), &yamlData)
-		close(done)
-	}()
+// prepareContent validates content in the given format ("YAML" or "JSON") against
+// emptiness and size limits and sanitizes it
+func (f *Flattener) prepareContent(content, format string) (string, error) {
+	if content == "" {
+		return "", ValidationError(format+" content cannot be empty", nil)
+	}
 
-	select {
-	case <-done:
-	case <-time.After(5 * time.Second):
-		return nil, TimeoutError("YAML parsing")
+	if strings.TrimSpace(content) == "" {
+		return "", ValidationError(format+" content cannot contain only whitespace", nil)
 	}
 
-	if err != nil {
-		return nil, ParsingError("failed to parse YAML content", err)
+	if len(content) > f.MaxYAMLSize {
+		return "", SizeLimitError(f.MaxYAMLSize, format+" content").limitedBy("MaxYAMLSize")
 	}
 
-	return f.FlattenYAML(yamlData)
+	return sanitizeYAMLContent(content), nil
+}
+
+// yamlLinePattern finds the line number in a yaml.v3 error message
+var yamlLinePattern = regexp.MustCompile(`line (\d+):`)
+
+// yamlParsingError wraps a yaml.v3 error in a ParsingError with the line it reports
+func yamlParsingError(message string, err error) *Error {
+	pe := ParsingError(message, err)
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		pe.atLine(line, 0)
+	}
+	return pe
 }
 
 // FlattenYAMLFile reads a YAML file and flattens it into a map with dot notation.
 // It validates the path for security (rejects directory traversal), checks file size
 // against MaxYAMLSize, and delegates to FlattenYAMLString for parsing and flattening.
 func (f *Flattener) FlattenYAMLFile(path string) (map[string]string, error) {
-	if path == "" {
-		return nil, ValidationError("file path cannot be empty", nil)
+	result, err := f.FlattenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return result.Values, nil
+}
+
+// FlattenFile reads a YAML file and flattens it, keeping the type of every value.
+// It applies the same path and size checks as FlattenYAMLFile.
+func (f *Flattener) FlattenFile(path string) (*Result, error) {
+	return f.FlattenFileContext(context.Background(), path)
+}
+
+// FlattenFileContext is FlattenFile with a context that stops parsing and flattening
+// when it is done
+func (f *Flattener) FlattenFileContext(ctx context.Context, path string) (*Result, error) {
+	content, err := f.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	cleanPath := filepath.Clean(path)
-	if strings.Contains(cleanPath, "..") {
-		return nil, PathSecurityError("file path contains invalid directory traversal patterns")
+	return f.withSourceFile(path).FlattenStringContext(ctx, content)
+}
+
+// ReadFile reads a YAML file after validating the path for security (rejects directory
+// traversal, paths outside AllowedBaseDirs and symbolic links refused by SymlinkPolicy)
+// and checking the file size against MaxYAMLSize. The path is checked before it is
+// opened, see checkPath.
+func (f *Flattener) ReadFile(path string) (string, error) {
+	absPath, err := f.checkPath(path)
+	if err != nil {
+		return "", err
 	}
 
-	absPath, err := filepath.Abs(cleanPath)
+	// the size is checked and the content read on the same open file, so the file
+	// cannot be replaced in between
+	file, err := os.Open(absPath) // #nosec G304 - absPath is validated
 	if err != nil {
-		return nil, FileAccessError(fmt.Sprintf("invalid file path: %s", err), err)
+		return "", FileAccessError(fmt.Sprintf("failed to access YAML file: %s", err), err)
 	}
+	defer file.Close()
 
-	fileInfo, err := os.Stat(absPath)
+	fileInfo, err := file.Stat()
 	if err != nil {
-		return nil, FileAccessError(fmt.Sprintf("failed to access YAML file: %s", err), err)
+		return "", FileAccessError(fmt.Sprintf("failed to access YAML file: %s", err), err)
 	}
 
 	if fileInfo.Size() > int64(f.MaxYAMLSize) {
-		return nil, SizeLimitError(f.MaxYAMLSize, "YAML file")
+		return "", SizeLimitError(f.MaxYAMLSize, "YAML file").limitedBy("MaxYAMLSize")
 	}
 
-	content, err := os.ReadFile(absPath) // #nosec G304 - absPath is validated
+	content, err := io.ReadAll(io.LimitReader(file, int64(f.MaxYAMLSize)+1))
 	if err != nil {
-		return nil, FileAccessError(fmt.Sprintf("failed to read YAML file: %s", err), err)
+		return "", FileAccessError(fmt.Sprintf("failed to read YAML file: %s", err), err)
+	}
+	if len(content) > f.MaxYAMLSize {
+		return "", SizeLimitError(f.MaxYAMLSize, "YAML file").limitedBy("MaxYAMLSize")
 	}
 
-	return f.FlattenYAMLString(string(content))
+	return string(content), nil
+}
+
+// objectKey returns the key segment for an object key: sanitized, and escaped according
+// to KeyEscaping
+func (f *Flattener) objectKey(key string) string {
+	return f.escapeKey(f.sanitizeKey(key))
 }
 
-// sanitizeKey sanitizes a map key to prevent injection attacks
-func sanitizeKey(key string) string {
+// sanitizeKey sanitizes a map key to prevent injection attacks and truncates it to
+// MaxKeyLength
+func (f *Flattener) sanitizeKey(key string) string {
 	key = strings.Map(func(r rune) rune {
 		if r < 0x20 || (r >= 0x7F && r <= 0x9F) {
 			return -1
@@ -217,9 +796,8 @@ func sanitizeKey(key string) string {
 		return r
 	}, key)
 	key = strings.TrimSpace(key)
-	const maxKeyLength = 1000
-	if len(key) > maxKeyLength {
-		return key[:maxKeyLength]
+	if len(key) > f.MaxKeyLength {
+		return key[:f.MaxKeyLength]
 	}
 	return key
 }
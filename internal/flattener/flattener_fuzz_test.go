@@ -92,7 +92,7 @@ func FuzzSanitizeKey(f *testing.F) {
 	f.Add("\tkey_with_tab")
 
 	f.Fuzz(func(t *testing.T, key string) {
-		result := sanitizeKey(key)
+		result := New().sanitizeKey(key)
 
 		// Ensure result doesn't contain control characters
 		for _, r := range result {
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -281,6 +282,374 @@ func TestFlattenYAMLString(t *testing.T) {
 	}
 }
 
+func TestFlattenKeyNotation(t *testing.T) {
+	yamlStr := `
+database:
+  primary:
+    host: db1
+  replicas:
+    - host: r1
+    - [a, b]
+`
+
+	tests := []struct {
+		name          string
+		separator     string
+		arrayStyle    ArrayStyle
+		arrayTemplate string
+		expected      map[string]string
+	}{
+		{
+			name:       "Default dot and brackets",
+			separator:  ".",
+			arrayStyle: ArrayStyleBrackets,
+			expected: map[string]string{
+				"database.primary.host":     "db1",
+				"database.replicas[0].host": "r1",
+				"database.replicas[1][0]":   "a",
+				"database.replicas[1][1]":   "b",
+			},
+		},
+		{
+			name:       "Double underscore separator",
+			separator:  "__",
+			arrayStyle: ArrayStyleBrackets,
+			expected: map[string]string{
+				"database__primary__host":     "db1",
+				"database__replicas[0]__host": "r1",
+				"database__replicas[1][0]":    "a",
+				"database__replicas[1][1]":    "b",
+			},
+		},
+		{
+			name:       "Slash separator with dotted index",
+			separator:  "/",
+			arrayStyle: ArrayStyleDotted,
+			expected: map[string]string{
+				"database/primary/host":    "db1",
+				"database/replicas/0/host": "r1",
+				"database/replicas/1/0":    "a",
+				"database/replicas/1/1":    "b",
+			},
+		},
+		{
+			name:       "Helm set style dotted index",
+			separator:  ".",
+			arrayStyle: ArrayStyleDotted,
+			expected: map[string]string{
+				"database.primary.host":    "db1",
+				"database.replicas.0.host": "r1",
+				"database.replicas.1.0":    "a",
+				"database.replicas.1.1":    "b",
+			},
+		},
+		{
+			name:          "Custom template",
+			separator:     "__",
+			arrayStyle:    ArrayStyleTemplate,
+			arrayTemplate: "__{index}",
+			expected: map[string]string{
+				"database__primary__host":     "db1",
+				"database__replicas__0__host": "r1",
+				"database__replicas__1__0":    "a",
+				"database__replicas__1__1":    "b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.Separator = tt.separator
+			f.ArrayStyle = tt.arrayStyle
+			f.ArrayTemplate = tt.arrayTemplate
+
+			result, err := f.FlattenYAMLString(yamlStr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("FlattenYAMLString() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenTypes(t *testing.T) {
+	result, err := New().FlattenString(`
+string_value: "8080"
+int_value: 8080
+float_value: 0.5
+bool_value: true
+null_value: null
+items: [1, "two", false]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]ValueType{
+		"string_value": ValueTypeString,
+		"int_value":    ValueTypeNumber,
+		"float_value":  ValueTypeNumber,
+		"bool_value":   ValueTypeBool,
+		"null_value":   ValueTypeNull,
+		"items[0]":     ValueTypeNumber,
+		"items[1]":     ValueTypeString,
+		"items[2]":     ValueTypeBool,
+	}
+	if !reflect.DeepEqual(result.Types, expected) {
+		t.Errorf("FlattenString() types = %v, want %v", result.Types, expected)
+	}
+	if result.Values["int_value"] != "8080" || result.Values["string_value"] != "8080" {
+		t.Errorf("FlattenString() values = %v", result.Values)
+	}
+}
+
+func TestFlattenEmptyCollections(t *testing.T) {
+	yamlStr := `
+tags: {}
+items: []
+nested:
+  labels: {}
+  name: app
+`
+
+	tests := []struct {
+		policy   EmptyCollections
+		expected map[string]string
+	}{
+		{
+			policy:   EmptyCollectionsOmit,
+			expected: map[string]string{"nested.name": "app"},
+		},
+		{
+			policy:   EmptyCollectionsLiteral,
+			expected: map[string]string{"tags": "{}", "items": "[]", "nested.labels": "{}", "nested.name": "app"},
+		},
+		{
+			policy:   EmptyCollectionsEmptyString,
+			expected: map[string]string{"tags": "", "items": "", "nested.labels": "", "nested.name": "app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			f := New()
+			f.EmptyCollections = tt.policy
+
+			result, err := f.FlattenString(yamlStr)
+			if err != nil {
+				t.Fatalf("FlattenString() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("FlattenString() = %v, want %v", result.Values, tt.expected)
+			}
+
+			// decoded input without nodes follows the same policy
+			json, err := f.FlattenJSON(`{"tags": {}, "items": [], "nested": {"labels": {}, "name": "app"}}`)
+			if err != nil {
+				t.Fatalf("FlattenJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(json.Values, tt.expected) {
+				t.Errorf("FlattenJSON() = %v, want %v", json.Values, tt.expected)
+			}
+		})
+	}
+
+	f := New()
+	f.EmptyCollections = EmptyCollectionsLiteral
+	result, err := f.FlattenString(yamlStr)
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+	if result.Types["tags"] != ValueTypeObject || result.Types["items"] != ValueTypeArray {
+		t.Errorf("Types = %v, want object and array", result.Types)
+	}
+	if result.Locations["items"] != (Location{Line: 3, Column: 8}) {
+		t.Errorf("Locations[items] = %v", result.Locations["items"])
+	}
+	if !reflect.DeepEqual(result.Keys, []string{"tags", "items", "nested.labels", "nested.name"}) {
+		t.Errorf("Keys = %v, want source order", result.Keys)
+	}
+
+	// an empty document has no key to keep
+	empty, err := f.FlattenJSON(`{}`)
+	if err != nil || len(empty.Values) != 0 {
+		t.Errorf("FlattenJSON({}) = %v, %v", empty.Values, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(f *Flattener)
+		wantErr bool
+	}{
+		{
+			name:    "Defaults",
+			modify:  func(_ *Flattener) {},
+			wantErr: false,
+		},
+		{
+			name:    "Empty separator",
+			modify:  func(f *Flattener) { f.Separator = "" },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown array style",
+			modify:  func(f *Flattener) { f.ArrayStyle = "parens" },
+			wantErr: true,
+		},
+		{
+			name: "Template without placeholder",
+			modify: func(f *Flattener) {
+				f.ArrayStyle = ArrayStyleTemplate
+				f.ArrayTemplate = "[]"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown array merge",
+			modify:  func(f *Flattener) { f.ArrayMerge = "zip" },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown empty collections policy",
+			modify:  func(f *Flattener) { f.EmptyCollections = "null" },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown key collision policy",
+			modify:  func(f *Flattener) { f.KeyCollisions = "random" },
+			wantErr: true,
+		},
+		{
+			name:    "Merge by key without key",
+			modify:  func(f *Flattener) { f.ArrayMerge = ArrayMergeByKey },
+			wantErr: true,
+		},
+		{
+			name:    "Zero nesting depth",
+			modify:  func(f *Flattener) { f.MaxNestingDepth = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "Result size above ceiling",
+			modify:  func(f *Flattener) { f.MaxResultSize = MaxResultSizeCeiling + 1 },
+			wantErr: true,
+		},
+		{
+			name:    "Raised result size",
+			modify:  func(f *Flattener) { f.MaxResultSize = 500000 },
+			wantErr: false,
+		},
+		{
+			name:    "Negative input size",
+			modify:  func(f *Flattener) { f.MaxYAMLSize = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "Zero key length",
+			modify:  func(f *Flattener) { f.MaxKeyLength = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "Zero alias expansions",
+			modify:  func(f *Flattener) { f.MaxAliasExpansions = 0 },
+			wantErr: false,
+		},
+		{
+			name:    "Disabled timeout",
+			modify:  func(f *Flattener) { f.Timeout = 0 },
+			wantErr: false,
+		},
+		{
+			name:    "Timeout above ceiling",
+			modify:  func(f *Flattener) { f.Timeout = TimeoutCeiling + time.Second },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			tt.modify(f)
+
+			err := f.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				assertErrorType(t, err, ErrTypeValidation)
+			}
+		})
+	}
+}
+
+func TestFlattenMaxKeyLength(t *testing.T) {
+	f := New()
+	f.MaxKeyLength = 4
+
+	result, err := f.FlattenYAMLString("database:\n  hostname: h\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := result["data.host"]; !ok {
+		t.Errorf("expected every key segment truncated to 4 bytes, got %v", result)
+	}
+}
+
+func TestLimitErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(f *Flattener)
+		input  string
+		limit  string
+	}{
+		{
+			name:   "nesting depth",
+			modify: func(f *Flattener) { f.MaxNestingDepth = 1 },
+			input:  "a:\n  b:\n    c: 1\n",
+			limit:  "MaxNestingDepth",
+		},
+		{
+			name:   "result size",
+			modify: func(f *Flattener) { f.MaxResultSize = 1 },
+			input:  "a: 1\nb: 2\n",
+			limit:  "MaxResultSize",
+		},
+		{
+			name:   "input size",
+			modify: func(f *Flattener) { f.MaxYAMLSize = 4 },
+			input:  "a: 1\nb: 2\n",
+			limit:  "MaxYAMLSize",
+		},
+		{
+			name:   "alias expansions",
+			modify: func(f *Flattener) { f.MaxAliasExpansions = 1 },
+			input:  "a: &x [1, 2]\nb: *x\n",
+			limit:  "MaxAliasExpansions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			tt.modify(f)
+
+			_, err := f.FlattenYAMLString(tt.input)
+			var fe *Error
+			if !errors.As(err, &fe) {
+				t.Fatalf("expected *Error, got %T: %v", err, err)
+			}
+			if fe.Limit != tt.limit {
+				t.Errorf("expected limit %q, got %q: %v", tt.limit, fe.Limit, err)
+			}
+		})
+	}
+}
+
 func TestFlattenYAMLFile(t *testing.T) {
 	t.Run("valid file", func(t *testing.T) {
 		dir := t.TempDir()
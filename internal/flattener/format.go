@@ -0,0 +1,244 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in input format names
+const (
+	FormatYAML       = "yaml"
+	FormatJSON       = "json"
+	FormatTOML       = "toml"
+	FormatINI        = "ini"
+	FormatProperties = "properties"
+)
+
+// Decoder parses the content of one input format into the maps, slices and scalars
+// that the flattener walks. Decoders that can stop part way through a parse should
+// also implement ContextDecoder.
+type Decoder interface {
+	Decode(content string) (interface{}, error)
+}
+
+// DecoderFunc adapts an ordinary function to the Decoder interface
+type DecoderFunc func(content string) (interface{}, error)
+
+// Decode calls fn(content)
+func (fn DecoderFunc) Decode(content string) (interface{}, error) {
+	return fn(content)
+}
+
+// Format describes an input format that can be flattened
+type Format struct {
+	// Name selects the format, e.g. "toml"
+	Name string
+	// Label is used in error messages, e.g. "TOML"
+	Label string
+	// Extensions are the file extensions detected as this format, including the dot
+	Extensions []string
+	// Decoder parses content of this format
+	Decoder Decoder
+}
+
+var (
+	formatsMu    sync.RWMutex
+	formats      = make(map[string]Format)
+	formatsByExt = make(map[string]string)
+)
+
+func init() {
+	RegisterFormat(Format{Name: FormatYAML, Label: "YAML", Extensions: []string{".yaml", ".yml"}, Decoder: ContextDecoderFunc(decodeYAML)})
+	RegisterFormat(Format{Name: FormatJSON, Label: "JSON", Extensions: []string{".json"}, Decoder: ContextDecoderFunc(decodeJSON)})
+	RegisterFormat(Format{Name: FormatTOML, Label: "TOML", Extensions: []string{".toml"}, Decoder: DecoderFunc(decodeTOML)})
+	RegisterFormat(Format{Name: FormatINI, Label: "INI", Extensions: []string{".ini", ".cfg", ".conf"}, Decoder: DecoderFunc(decodeINI)})
+	RegisterFormat(Format{Name: FormatProperties, Label: "properties", Extensions: []string{".properties"}, Decoder: DecoderFunc(decodeProperties)})
+}
+
+// RegisterFormat makes an input format available by name and by file extension,
+// replacing any format previously registered under the same name or extension.
+func RegisterFormat(format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if format.Label == "" {
+		format.Label = format.Name
+	}
+	formats[format.Name] = format
+	for _, ext := range format.Extensions {
+		formatsByExt[strings.ToLower(ext)] = format.Name
+	}
+}
+
+// Formats returns the names of all registered input formats in sorted order
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatForPath returns the name of the format registered for the extension of path
+func FormatForPath(path string) (string, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	name, ok := formatsByExt[strings.ToLower(filepath.Ext(path))]
+	return name, ok
+}
+
+func lookupFormat(name string) (Format, error) {
+	formatsMu.RLock()
+	format, ok := formats[name]
+	formatsMu.RUnlock()
+
+	if !ok {
+		return Format{}, ValidationError(fmt.Sprintf("unsupported format %q, expected one of: %s", name, strings.Join(Formats(), ", ")), nil)
+	}
+	return format, nil
+}
+
+// FlattenFormat takes content in the named input format and flattens it, keeping the
+// type of every value
+func (f *Flattener) FlattenFormat(content, formatName string) (*Result, error) {
+	return f.FlattenFormatContext(context.Background(), content, formatName)
+}
+
+// FlattenFormatContext is FlattenFormat with a context that stops parsing and
+// flattening when it is done. Decoders that do not implement ContextDecoder run to
+// completion, and the context is checked once they return.
+func (f *Flattener) FlattenFormatContext(ctx context.Context, content, formatName string) (*Result, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	data, err := f.decodeFormat(ctx, content, formatName)
+	if err != nil {
+		return nil, err
+	}
+	return f.FlattenContext(ctx, data)
+}
+
+// decodeFormat decodes content in the named input format, failing if it contains no data
+func (f *Flattener) decodeFormat(ctx context.Context, content, formatName string) (interface{}, error) {
+	format, err := lookupFormat(formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = f.prepareContent(content, format.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	operation := format.Label + " parsing"
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, operation)
+	}
+
+	var data interface{}
+	if decoder, ok := format.Decoder.(ContextDecoder); ok {
+		data, err = decoder.DecodeContext(ctx, content)
+	} else {
+		data, err = format.Decoder.Decode(content)
+	}
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, operation)
+	}
+	if err != nil {
+		var fe *Error
+		if errors.As(err, &fe) {
+			return nil, inFile(err, f.SourceFile)
+		}
+		return nil, ParsingError(fmt.Sprintf("failed to parse %s content", format.Label), err)
+	}
+
+	if data == nil {
+		return nil, ValidationError(format.Label+" content contains no data", nil)
+	}
+	return data, nil
+}
+
+// FlattenFileFormat reads a file and flattens it as the named input format. An empty
+// format is detected from the file extension, falling back to YAML for unknown
+// extensions. It applies the same path and size checks as FlattenYAMLFile.
+func (f *Flattener) FlattenFileFormat(path, formatName string) (*Result, error) {
+	return f.FlattenFileFormatContext(context.Background(), path, formatName)
+}
+
+// FlattenFileFormatContext is FlattenFileFormat with a context that stops parsing and
+// flattening when it is done
+func (f *Flattener) FlattenFileFormatContext(ctx context.Context, path, formatName string) (*Result, error) {
+	content, formatName, err := f.readFileFormat(path, formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.withSourceFile(path).FlattenFormatContext(ctx, content, formatName)
+}
+
+// readFileFormat reads the file at path and returns its content and format: formatName,
+// or when it is empty the format of the file extension, falling back to YAML
+func (f *Flattener) readFileFormat(path, formatName string) (string, string, error) {
+	if formatName == "" {
+		formatName = FormatYAML
+		if detected, ok := FormatForPath(path); ok {
+			formatName = detected
+		}
+	}
+
+	if _, err := lookupFormat(formatName); err != nil {
+		return "", "", err
+	}
+
+	content, err := f.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	return content, formatName, nil
+}
+
+// decodeYAML decodes the first document of a YAML stream into a node, so that it is
+// walked in source order
+func decodeYAML(ctx context.Context, content string) (interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(newContextReader(ctx, content)).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, yamlParsingError("failed to parse YAML content", err)
+	}
+	if isEmptyDocument(&doc) {
+		return nil, nil
+	}
+	return &doc, nil
+}
+
+// decodeTOML decodes a TOML document. The TOML decoder does not report key order, so
+// tables are walked in sorted key order.
+func decodeTOML(content string) (interface{}, error) {
+	var data map[string]interface{}
+	if err := toml.Unmarshal([]byte(content), &data); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, column := decodeErr.Position()
+			return nil, TOMLParsingError("invalid TOML", err).atLine(row, column)
+		}
+		return nil, TOMLParsingError("failed to parse TOML content", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,283 @@
+package flattener
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		content  string
+		expected map[string]string
+		types    map[string]ValueType
+		wantErr  ErrorType
+	}{
+		{
+			name:   "TOML tables and arrays of tables",
+			format: FormatTOML,
+			content: `
+title = "app"
+released = 1979-05-27T07:32:00Z
+
+[database]
+port = 5432
+enabled = true
+ratio = 0.5
+
+[[database.replicas]]
+host = "r1"
+
+[[database.replicas]]
+host = "r2"
+`,
+			expected: map[string]string{
+				"title":                     "app",
+				"released":                  "1979-05-27T07:32:00Z",
+				"database.port":             "5432",
+				"database.enabled":          "true",
+				"database.ratio":            "0.5",
+				"database.replicas[0].host": "r1",
+				"database.replicas[1].host": "r2",
+			},
+			types: map[string]ValueType{
+				"title":                     ValueTypeString,
+				"released":                  ValueTypeString,
+				"database.port":             ValueTypeNumber,
+				"database.enabled":          ValueTypeBool,
+				"database.ratio":            ValueTypeNumber,
+				"database.replicas[0].host": ValueTypeString,
+				"database.replicas[1].host": ValueTypeString,
+			},
+		},
+		{
+			name:    "Invalid TOML",
+			format:  FormatTOML,
+			content: "a = = 1",
+			wantErr: ErrTypeTOMLParsing,
+		},
+		{
+			name:   "INI sections",
+			format: FormatINI,
+			content: `
+; global settings
+name = app
+
+[database.primary]
+host = db1
+port: 5432
+password = "se;cret"
+
+[cache]
+# comment
+host = 'redis'
+`,
+			expected: map[string]string{
+				"name":                      "app",
+				"database.primary.host":     "db1",
+				"database.primary.port":     "5432",
+				"database.primary.password": "se;cret",
+				"cache.host":                "redis",
+			},
+			types: map[string]ValueType{
+				"name":                      ValueTypeString,
+				"database.primary.host":     ValueTypeString,
+				"database.primary.port":     ValueTypeString,
+				"database.primary.password": ValueTypeString,
+				"cache.host":                ValueTypeString,
+			},
+		},
+		{
+			name:    "INI repeated sections merge",
+			format:  FormatINI,
+			content: "[a]\nx = 1\n[b]\ny = 2\n[a]\nz = 3\n",
+			expected: map[string]string{
+				"a.x": "1",
+				"a.z": "3",
+				"b.y": "2",
+			},
+			types: map[string]ValueType{
+				"a.x": ValueTypeString,
+				"a.z": ValueTypeString,
+				"b.y": ValueTypeString,
+			},
+		},
+		{
+			name:    "INI duplicate key",
+			format:  FormatINI,
+			content: "[a]\nx = 1\nx = 2\n",
+			wantErr: ErrTypeINIParsing,
+		},
+		{
+			name:    "INI line without delimiter",
+			format:  FormatINI,
+			content: "[a]\njust a line\n",
+			wantErr: ErrTypeINIParsing,
+		},
+		{
+			name:    "INI unterminated section",
+			format:  FormatINI,
+			content: "[a\nx = 1\n",
+			wantErr: ErrTypeINIParsing,
+		},
+		{
+			name:   "Properties",
+			format: FormatProperties,
+			content: `
+# comment
+! also a comment
+database.host = db1
+database.port:5432
+greeting Hello World
+path = C:\\temp
+multi = first \
+        second
+key\ with\ spaces = value
+emoji = \uD83D\uDE00 \u00e9
+empty
+`,
+			expected: map[string]string{
+				"database.host":   "db1",
+				"database.port":   "5432",
+				"greeting":        "Hello World",
+				"path":            `C:\temp`,
+				"multi":           "first second",
+				"key with spaces": "value",
+				"emoji":           "😀 é",
+				"empty":           "",
+			},
+			types: map[string]ValueType{
+				"database.host":   ValueTypeString,
+				"database.port":   ValueTypeString,
+				"greeting":        ValueTypeString,
+				"path":            ValueTypeString,
+				"multi":           ValueTypeString,
+				"key with spaces": ValueTypeString,
+				"emoji":           ValueTypeString,
+				"empty":           ValueTypeString,
+			},
+		},
+		{
+			name:    "Properties malformed unicode escape",
+			format:  FormatProperties,
+			content: `key = \u00zz`,
+			wantErr: ErrTypePropertiesParsing,
+		},
+		{
+			name:    "Unknown format",
+			format:  "xml",
+			content: "<a/>",
+			wantErr: ErrTypeValidation,
+		},
+		{
+			name:    "Empty content",
+			format:  FormatTOML,
+			content: "  ",
+			wantErr: ErrTypeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().FlattenFormat(tt.content, tt.format)
+			if tt.wantErr != "" {
+				assertErrorType(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("FlattenFormat() values = %v, want %v", result.Values, tt.expected)
+			}
+			if !reflect.DeepEqual(result.Types, tt.types) {
+				t.Errorf("FlattenFormat() types = %v, want %v", result.Types, tt.types)
+			}
+		})
+	}
+}
+
+func TestFlattenFileFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.toml":       "[server]\nport = 8080\n",
+		"config.ini":        "[server]\nport = 8080\n",
+		"config.properties": "server.port=8080\n",
+		"config.yml":        "server:\n  port: 8080\n",
+		"config.txt":        "server:\n  port: 8080\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{"server.port": "8080"}
+
+	t.Run("detect by extension", func(t *testing.T) {
+		for name := range files {
+			result, err := New().FlattenFileFormat(filepath.Join(dir, name), "")
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+			if !reflect.DeepEqual(result.Values, expected) {
+				t.Errorf("%s: FlattenFileFormat() = %v, want %v", name, result.Values, expected)
+			}
+		}
+	})
+
+	t.Run("explicit format overrides extension", func(t *testing.T) {
+		path := filepath.Join(dir, "settings.conf.txt")
+		if err := os.WriteFile(path, []byte("server.port=8080\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		result, err := New().FlattenFileFormat(path, FormatProperties)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Values, expected) {
+			t.Errorf("FlattenFileFormat() = %v, want %v", result.Values, expected)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := New().FlattenFileFormat(filepath.Join(dir, "config.toml"), "xml")
+		assertErrorType(t, err, ErrTypeValidation)
+	})
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat(Format{
+		Name:       "test-lines",
+		Extensions: []string{".lines"},
+		Decoder: DecoderFunc(func(content string) (interface{}, error) {
+			var lines []interface{}
+			for _, line := range strings.Split(content, "\n") {
+				lines = append(lines, line)
+			}
+			return lines, nil
+		}),
+	})
+	t.Cleanup(func() {
+		formatsMu.Lock()
+		delete(formats, "test-lines")
+		delete(formatsByExt, ".lines")
+		formatsMu.Unlock()
+	})
+
+	if format, ok := FormatForPath("data.LINES"); !ok || format != "test-lines" {
+		t.Errorf("FormatForPath() = %q, %v", format, ok)
+	}
+
+	result, err := New().FlattenFormat("a\nb", "test-lines")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Values, map[string]string{"[0]": "a", "[1]": "b"}) {
+		t.Errorf("FlattenFormat() = %v", result.Values)
+	}
+}
@@ -0,0 +1,77 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"fmt"
+	"strings"
+)
+
+// decodeINI decodes INI content. Keys before the first section are top-level keys and
+// every [section] becomes a nested object; section names are used as a single key
+// segment, so [database.primary] is not split at the dot. Lines starting with ; or #
+// are comments, keys and values are separated by = or :, and values surrounded by
+// matching quotes are unquoted. All values are strings. Repeated sections are merged,
+// but a key may only appear once per section.
+func decodeINI(content string) (interface{}, error) {
+	root := newOrderedMap()
+	current := root
+	sectionName := ""
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, INIParsingError("unterminated section header", nil).atLine(lineNum, 0)
+			}
+			sectionName = strings.TrimSpace(line[1 : len(line)-1])
+			if sectionName == "" {
+				return nil, INIParsingError("empty section name", nil).atLine(lineNum, 0)
+			}
+			existing, ok := root.get(sectionName)
+			switch section := existing.(type) {
+			case *orderedMap:
+				current = section
+			case nil:
+				if ok {
+					return nil, INIParsingError(fmt.Sprintf("section %q has the same name as a top-level key", sectionName), nil).atLine(lineNum, 0)
+				}
+				current = newOrderedMap()
+				root.set(sectionName, current)
+			default:
+				return nil, INIParsingError(fmt.Sprintf("section %q has the same name as a top-level key", sectionName), nil).atLine(lineNum, 0)
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return nil, INIParsingError("expected key = value", nil).atLine(lineNum, 0)
+		}
+		key := strings.TrimSpace(line[:sep])
+		if key == "" {
+			return nil, INIParsingError("empty key", nil).atLine(lineNum, 0)
+		}
+		if _, ok := current.get(key); ok {
+			if sectionName == "" {
+				return nil, INIParsingError(fmt.Sprintf("duplicate key %q", key), nil).atLine(lineNum, 0)
+			}
+			return nil, INIParsingError(fmt.Sprintf("duplicate key %q in section %q", key, sectionName), nil).atLine(lineNum, 0)
+		}
+		current.set(key, unquoteINIValue(strings.TrimSpace(line[sep+1:])))
+	}
+
+	return root, nil
+}
+
+// unquoteINIValue removes one pair of matching surrounding quotes
+func unquoteINIValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
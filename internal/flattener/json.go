@@ -0,0 +1,131 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// FlattenJSONString takes a JSON string and flattens it into a map with dot notation
+func (f *Flattener) FlattenJSONString(jsonContent string) (map[string]string, error) {
+	return f.FlattenJSONStringContext(context.Background(), jsonContent)
+}
+
+// FlattenJSONStringContext is FlattenJSONString with a context that stops parsing and
+// flattening when it is done
+func (f *Flattener) FlattenJSONStringContext(ctx context.Context, jsonContent string) (map[string]string, error) {
+	result, err := f.FlattenJSONContext(ctx, jsonContent)
+	if err != nil {
+		return nil, err
+	}
+	return result.Values, nil
+}
+
+// FlattenJSON takes a JSON string and flattens it, keeping the type of every value.
+// Numbers are decoded as json.Number so that big integers and decimals keep their
+// exact text instead of going through float64.
+func (f *Flattener) FlattenJSON(jsonContent string) (*Result, error) {
+	return f.FlattenJSONContext(context.Background(), jsonContent)
+}
+
+// FlattenJSONContext is FlattenJSON with a context that stops parsing and flattening
+// when it is done
+func (f *Flattener) FlattenJSONContext(ctx context.Context, jsonContent string) (*Result, error) {
+	return f.FlattenFormatContext(ctx, jsonContent, FormatJSON)
+}
+
+// FlattenJSONFile reads a JSON file and flattens it, keeping the type of every value.
+// It applies the same path and size checks as FlattenYAMLFile.
+func (f *Flattener) FlattenJSONFile(path string) (*Result, error) {
+	return f.FlattenFileFormat(path, FormatJSON)
+}
+
+// decodeJSON decodes a single JSON value, rejecting trailing content. Objects are
+// decoded token by token so that their keys keep the order of the source.
+func decodeJSON(ctx context.Context, content string) (interface{}, error) {
+	decoder := json.NewDecoder(newContextReader(ctx, content))
+	decoder.UseNumber()
+
+	data, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, jsonSyntaxError(content, err)
+	}
+	end := decoder.InputOffset()
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		trailing := end + int64(len(content[end:])-len(strings.TrimLeft(content[end:], " \t\r\n")))
+		line, column := jsonPosition(content, trailing)
+		return nil, JSONParsingError("unexpected content after the top-level value", nil).atLine(line, column)
+	}
+
+	return data, nil
+}
+
+// decodeJSONValue decodes the next value of the decoder. When a key repeats, the last
+// value wins as with encoding/json, but the key keeps its first position.
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		object := newOrderedMap()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(key.(string), value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	default:
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	}
+}
+
+// jsonSyntaxError converts a decoding error into a JSONParsingError pointing at the
+// offending position when it is known
+func jsonSyntaxError(content string, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset counts the bytes read including the offending character
+		line, column := jsonPosition(content, syntaxErr.Offset-1)
+		return JSONParsingError("invalid JSON", err).atLine(line, column)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return JSONParsingError("unexpected end of JSON content", err)
+	}
+	return JSONParsingError("failed to parse JSON content", err)
+}
+
+// jsonPosition renders a byte offset into content as a 1-based line and column
+func jsonPosition(content string, offset int64) (line, column int) {
+	offset = max(0, min(offset, int64(len(content))))
+	before := content[:offset]
+	line = strings.Count(before, "\n") + 1
+	column = int(offset) - strings.LastIndex(before, "\n")
+	return line, column
+}
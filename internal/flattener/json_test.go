@@ -0,0 +1,157 @@
+package flattener
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonStr  string
+		expected map[string]string
+		types    map[string]ValueType
+		wantErr  ErrorType
+	}{
+		{
+			name:    "Nested object and array",
+			jsonStr: `{"server": {"port": 8080, "hosts": ["a", "b"]}, "debug": false, "extra": null}`,
+			expected: map[string]string{
+				"server.port":     "8080",
+				"server.hosts[0]": "a",
+				"server.hosts[1]": "b",
+				"debug":           "false",
+				"extra":           "",
+			},
+			types: map[string]ValueType{
+				"server.port":     ValueTypeNumber,
+				"server.hosts[0]": ValueTypeString,
+				"server.hosts[1]": ValueTypeString,
+				"debug":           ValueTypeBool,
+				"extra":           ValueTypeNull,
+			},
+		},
+		{
+			name:    "Big numbers keep their exact text",
+			jsonStr: `{"id": 123456789012345678901234567890, "price": 0.10000000000000000001, "exp": 1e400}`,
+			expected: map[string]string{
+				"id":    "123456789012345678901234567890",
+				"price": "0.10000000000000000001",
+				"exp":   "1e400",
+			},
+			types: map[string]ValueType{
+				"id":    ValueTypeNumber,
+				"price": ValueTypeNumber,
+				"exp":   ValueTypeNumber,
+			},
+		},
+		{
+			name:    "Top-level array",
+			jsonStr: `[{"name": "a"}, {"name": "b"}]`,
+			expected: map[string]string{
+				"[0].name": "a",
+				"[1].name": "b",
+			},
+			types: map[string]ValueType{
+				"[0].name": ValueTypeString,
+				"[1].name": ValueTypeString,
+			},
+		},
+		{
+			name:    "Strings are not coerced",
+			jsonStr: `{"version": "1.10", "flag": "yes"}`,
+			expected: map[string]string{
+				"version": "1.10",
+				"flag":    "yes",
+			},
+			types: map[string]ValueType{
+				"version": ValueTypeString,
+				"flag":    ValueTypeString,
+			},
+		},
+		{
+			name:    "Invalid syntax",
+			jsonStr: "{\n  \"a\": 1,\n  \"b\": }\n",
+			wantErr: ErrTypeJSONParsing,
+		},
+		{
+			name:    "Truncated content",
+			jsonStr: `{"a": [1, 2`,
+			wantErr: ErrTypeJSONParsing,
+		},
+		{
+			name:    "Trailing content",
+			jsonStr: `{"a": 1} {"b": 2}`,
+			wantErr: ErrTypeJSONParsing,
+		},
+		{
+			name:    "YAML is not JSON",
+			jsonStr: "a: 1",
+			wantErr: ErrTypeJSONParsing,
+		},
+		{
+			name:    "Null document",
+			jsonStr: "null",
+			wantErr: ErrTypeValidation,
+		},
+		{
+			name:    "Whitespace only",
+			jsonStr: "  \n ",
+			wantErr: ErrTypeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().FlattenJSON(tt.jsonStr)
+			if tt.wantErr != "" {
+				assertErrorType(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("FlattenJSON() values = %v, want %v", result.Values, tt.expected)
+			}
+			if !reflect.DeepEqual(result.Types, tt.types) {
+				t.Errorf("FlattenJSON() types = %v, want %v", result.Types, tt.types)
+			}
+		})
+	}
+}
+
+func TestFlattenJSONErrorPosition(t *testing.T) {
+	tests := []struct {
+		jsonStr  string
+		position string
+	}{
+		{jsonStr: "{\n  \"a\": 1,\n  \"b\": }\n", position: "line 3, column 8"},
+		{jsonStr: "{\"a\": 1}\n  [2]", position: "line 2, column 3"},
+	}
+
+	for _, tt := range tests {
+		_, err := New().FlattenJSON(tt.jsonStr)
+		if err == nil || !strings.Contains(err.Error(), tt.position) {
+			t.Errorf("expected error pointing at %s, got %v", tt.position, err)
+		}
+	}
+}
+
+func TestFlattenJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"a": {"b": 1}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().FlattenJSONFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Values, map[string]string{"a.b": "1"}) {
+		t.Errorf("FlattenJSONFile() = %v", result.Values)
+	}
+}
@@ -0,0 +1,262 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyEscaping controls how object keys containing the separator or an array marker are
+// written, so that keys like kubernetes.io/ingress.class can be split back into their
+// segments
+type KeyEscaping string
+
+const (
+	// KeyEscapingNone writes object keys as they are
+	KeyEscapingNone KeyEscaping = "none"
+	// KeyEscapingBackslash puts a backslash before the separator, array markers and
+	// backslashes inside object keys: a.kubernetes\.io/ingress\.class
+	KeyEscapingBackslash KeyEscaping = "backslash"
+	// KeyEscapingQuote wraps object keys containing the separator, an array marker or a
+	// leading quote in double quotes, escaping quotes and backslashes inside them with a
+	// backslash: a."kubernetes.io/ingress.class"
+	KeyEscapingQuote KeyEscaping = "quote"
+)
+
+// keyEscape escapes one character of an object key under KeyEscapingBackslash and
+// inside quoted keys under KeyEscapingQuote
+const keyEscape = `\`
+
+// keyQuote delimits a quoted object key under KeyEscapingQuote
+const keyQuote = `"`
+
+// KeySegment is one step of a flattened key: either an object key or an array index
+type KeySegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// keyEscaping returns the escaping applied to object keys. KeyCollisionsEscape implies
+// KeyEscapingBackslash unless KeyEscaping selects another scheme.
+func (f *Flattener) keyEscaping() KeyEscaping {
+	if f.KeyEscaping == KeyEscapingNone && f.KeyCollisions == KeyCollisionsEscape {
+		return KeyEscapingBackslash
+	}
+	return f.KeyEscaping
+}
+
+// escapeKey escapes one object key according to the configured key escaping
+func (f *Flattener) escapeKey(key string) string {
+	switch f.keyEscaping() {
+	case KeyEscapingBackslash:
+		var b strings.Builder
+		for i := 0; i < len(key); i++ {
+			if strings.HasPrefix(key[i:], keyEscape) || f.isMarker(key, i) {
+				b.WriteString(keyEscape)
+			}
+			b.WriteByte(key[i])
+		}
+		return b.String()
+	case KeyEscapingQuote:
+		if !f.needsQuote(key) {
+			return key
+		}
+		key = strings.ReplaceAll(key, keyEscape, keyEscape+keyEscape)
+		key = strings.ReplaceAll(key, keyQuote, keyEscape+keyQuote)
+		return keyQuote + key + keyQuote
+	default:
+		return key
+	}
+}
+
+// needsQuote reports whether an object key must be quoted under KeyEscapingQuote
+func (f *Flattener) needsQuote(key string) bool {
+	if strings.HasPrefix(key, keyQuote) {
+		return true
+	}
+	for i := 0; i < len(key); i++ {
+		if f.isMarker(key, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMarker reports whether position i of an object key starts something ParseKey would
+// read as structure: the separator, or the start of an array marker
+func (f *Flattener) isMarker(key string, i int) bool {
+	if strings.HasPrefix(key[i:], f.Separator) {
+		return true
+	}
+	switch f.ArrayStyle {
+	case ArrayStyleDotted:
+		// a key of only digits reads as an index
+		return i == 0 && strings.Trim(key, "0123456789") == ""
+	case ArrayStyleTemplate:
+		prefix, _, _ := strings.Cut(f.ArrayTemplate, IndexPlaceholder)
+		if prefix == "" {
+			return isDigit(key[i])
+		}
+		return strings.HasPrefix(key[i:], prefix)
+	default:
+		return key[i] == '['
+	}
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// ParseKey splits a flattened key into its segments using the configured separator,
+// array style and key escaping, undoing the escapes of KeyEscaping. Array markers are
+// only recognised when followed by a separator, another array marker or the end of the
+// key, so literal brackets inside key names are kept as part of the key. Malformed
+// escapes, such as an unterminated quote, are a validation error.
+func (f *Flattener) ParseKey(key string) ([]KeySegment, error) {
+	escaping := f.keyEscaping()
+	var segments []KeySegment
+	var current strings.Builder
+	afterIndex := false
+	quoted := false
+	segmentStart := true
+
+	for i := 0; i < len(key); {
+		if escaping == KeyEscapingQuote && segmentStart && strings.HasPrefix(key[i:], keyQuote) {
+			next, err := readQuoted(key, i, &current)
+			if err != nil {
+				return nil, err
+			}
+			quoted = true
+			segmentStart = false
+			i = next
+			continue
+		}
+
+		if escaping == KeyEscapingBackslash && strings.HasPrefix(key[i:], keyEscape) {
+			i += len(keyEscape)
+			if i >= len(key) {
+				return nil, ValidationError(fmt.Sprintf("key %q ends with an unfinished escape", key), nil).at(key)
+			}
+			current.WriteByte(key[i])
+			segmentStart = false
+			i++
+			continue
+		}
+
+		if index, next, ok := f.matchIndex(key, i); ok {
+			if current.Len() > 0 || quoted {
+				segments = append(segments, KeySegment{Key: current.String()})
+				current.Reset()
+			}
+			segments = append(segments, KeySegment{Index: index, IsIndex: true})
+			afterIndex = true
+			quoted = false
+			segmentStart = false
+			i = next
+			continue
+		}
+
+		if strings.HasPrefix(key[i:], f.Separator) {
+			if current.Len() > 0 || quoted || !afterIndex {
+				segments = append(segments, KeySegment{Key: current.String()})
+			}
+			current.Reset()
+			afterIndex = false
+			quoted = false
+			segmentStart = true
+			i += len(f.Separator)
+			continue
+		}
+
+		current.WriteByte(key[i])
+		segmentStart = false
+		i++
+	}
+
+	if current.Len() > 0 || quoted || !afterIndex {
+		segments = append(segments, KeySegment{Key: current.String()})
+	}
+
+	return segments, nil
+}
+
+// readQuoted reads the quoted object key starting at position i of key into current and
+// returns the position after the closing quote
+func readQuoted(key string, i int, current *strings.Builder) (int, error) {
+	for j := i + len(keyQuote); j < len(key); j++ {
+		switch {
+		case strings.HasPrefix(key[j:], keyEscape) && j+len(keyEscape) < len(key):
+			j += len(keyEscape)
+			current.WriteByte(key[j])
+		case strings.HasPrefix(key[j:], keyQuote):
+			return j + len(keyQuote), nil
+		default:
+			current.WriteByte(key[j])
+		}
+	}
+	return 0, ValidationError(fmt.Sprintf("key %q has an unterminated quote", key), nil).at(key)
+}
+
+// FormatKey joins segments into a flattened key using the configured separator, array
+// style and key escaping. It is the inverse of ParseKey.
+func (f *Flattener) FormatKey(segments []KeySegment) string {
+	key := ""
+	for i, seg := range segments {
+		switch {
+		case seg.IsIndex:
+			key = f.indexKey(key, seg.Index)
+		case i == 0:
+			key = f.escapeKey(seg.Key)
+		default:
+			key = key + f.Separator + f.escapeKey(seg.Key)
+		}
+	}
+	return key
+}
+
+// matchIndex reports whether an array marker starts at position i of key and returns
+// the index and the position after the marker
+func (f *Flattener) matchIndex(key string, i int) (int, int, bool) {
+	var prefix, suffix string
+	switch f.ArrayStyle {
+	case ArrayStyleDotted:
+		// a dotted index at the very start of the key has no leading separator
+		if i > 0 {
+			prefix = f.Separator
+		}
+	case ArrayStyleTemplate:
+		prefix, suffix, _ = strings.Cut(f.ArrayTemplate, IndexPlaceholder)
+	default:
+		prefix, suffix = "[", "]"
+	}
+
+	if !strings.HasPrefix(key[i:], prefix) {
+		return 0, 0, false
+	}
+
+	start := i + len(prefix)
+	end := start
+	for end < len(key) && isDigit(key[end]) {
+		end++
+	}
+	if end == start || !strings.HasPrefix(key[end:], suffix) {
+		return 0, 0, false
+	}
+
+	index, err := strconv.Atoi(key[start:end])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	next := end + len(suffix)
+	if next != len(key) && !strings.HasPrefix(key[next:], f.Separator) {
+		if _, _, ok := f.matchIndex(key, next); !ok {
+			return 0, 0, false
+		}
+	}
+
+	return index, next, true
+}
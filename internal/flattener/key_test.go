@@ -0,0 +1,198 @@
+package flattener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenKeyEscaping(t *testing.T) {
+	yamlStr := `metadata:
+  annotations:
+    kubernetes.io/ingress.class: nginx
+  labels:
+    app[beta]: "true"
+    'say "hi"': x
+`
+
+	tests := []struct {
+		escaping KeyEscaping
+		expected map[string]string
+	}{
+		{
+			escaping: KeyEscapingNone,
+			expected: map[string]string{
+				"metadata.annotations.kubernetes.io/ingress.class": "nginx",
+				"metadata.labels.app[beta]":                        "true",
+				`metadata.labels.say "hi"`:                         "x",
+			},
+		},
+		{
+			escaping: KeyEscapingBackslash,
+			expected: map[string]string{
+				`metadata.annotations.kubernetes\.io/ingress\.class`: "nginx",
+				`metadata.labels.app\[beta]`:                         "true",
+				`metadata.labels.say "hi"`:                           "x",
+			},
+		},
+		{
+			escaping: KeyEscapingQuote,
+			expected: map[string]string{
+				`metadata.annotations."kubernetes.io/ingress.class"`: "nginx",
+				`metadata.labels."app[beta]"`:                        "true",
+				`metadata.labels.say "hi"`:                           "x",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.escaping), func(t *testing.T) {
+			f := New()
+			f.KeyEscaping = tt.escaping
+
+			flat, err := f.FlattenYAMLString(yamlStr)
+			if err != nil {
+				t.Fatalf("FlattenYAMLString() error = %v", err)
+			}
+			if !reflect.DeepEqual(flat, tt.expected) {
+				t.Errorf("FlattenYAMLString() = %v, want %v", flat, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		escaping   KeyEscaping
+		arrayStyle ArrayStyle
+		key        string
+		expected   []KeySegment
+		formatted  string
+		wantErr    bool
+	}{
+		{
+			name:     "plain",
+			escaping: KeyEscapingNone,
+			key:      "a.b[0].c",
+			expected: []KeySegment{{Key: "a"}, {Key: "b"}, {Index: 0, IsIndex: true}, {Key: "c"}},
+		},
+		{
+			name:     "backslash",
+			escaping: KeyEscapingBackslash,
+			key:      `annotations.kubernetes\.io/ingress\.class`,
+			expected: []KeySegment{{Key: "annotations"}, {Key: "kubernetes.io/ingress.class"}},
+		},
+		{
+			name:     "backslash brackets",
+			escaping: KeyEscapingBackslash,
+			key:      `labels.app\[0][1]`,
+			expected: []KeySegment{{Key: "labels"}, {Key: "app[0]"}, {Index: 1, IsIndex: true}},
+		},
+		{
+			name:     "quote",
+			escaping: KeyEscapingQuote,
+			key:      `annotations."kubernetes.io/ingress.class"[2]."\"hi\" there"`,
+			expected: []KeySegment{{Key: "annotations"}, {Key: "kubernetes.io/ingress.class"}, {Index: 2, IsIndex: true}, {Key: `"hi" there`}},
+		},
+		{
+			name:      "needless quotes",
+			escaping:  KeyEscapingQuote,
+			key:       `a."".b."c"`,
+			expected:  []KeySegment{{Key: "a"}, {Key: ""}, {Key: "b"}, {Key: "c"}},
+			formatted: "a..b.c",
+		},
+		{
+			name:       "dotted index key",
+			escaping:   KeyEscapingBackslash,
+			arrayStyle: ArrayStyleDotted,
+			key:        `a.\0.1`,
+			expected:   []KeySegment{{Key: "a"}, {Key: "0"}, {Index: 1, IsIndex: true}},
+		},
+		{
+			name:     "unterminated quote",
+			escaping: KeyEscapingQuote,
+			key:      `a."b.c`,
+			wantErr:  true,
+		},
+		{
+			name:     "unfinished escape",
+			escaping: KeyEscapingBackslash,
+			key:      `a.b\`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.KeyEscaping = tt.escaping
+			if tt.arrayStyle != "" {
+				f.ArrayStyle = tt.arrayStyle
+			}
+
+			segments, err := f.ParseKey(tt.key)
+			if tt.wantErr {
+				assertErrorType(t, err, ErrTypeValidation)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKey() error = %v", err)
+			}
+			if !reflect.DeepEqual(segments, tt.expected) {
+				t.Errorf("ParseKey() = %v, want %v", segments, tt.expected)
+			}
+			formatted := tt.key
+			if tt.formatted != "" {
+				formatted = tt.formatted
+			}
+			if key := f.FormatKey(segments); key != formatted {
+				t.Errorf("FormatKey() = %q, want %q", key, formatted)
+			}
+		})
+	}
+}
+
+func TestUnflattenKeyEscapingRoundTrip(t *testing.T) {
+	yamlStr := `"a.b": dotted
+a:
+  b: nested
+"0":
+  - "[x]": 1
+"__0__": template
+"\"hi\" there": quoted
+`
+
+	for _, escaping := range []KeyEscaping{KeyEscapingBackslash, KeyEscapingQuote} {
+		for _, style := range []ArrayStyle{ArrayStyleBrackets, ArrayStyleDotted, ArrayStyleTemplate} {
+			t.Run(string(escaping)+"/"+string(style), func(t *testing.T) {
+				f := New()
+				f.KeyEscaping = escaping
+				f.ArrayStyle = style
+				f.ArrayTemplate = "__{index}__"
+
+				flat, err := f.FlattenYAMLString(yamlStr)
+				if err != nil {
+					t.Fatalf("FlattenYAMLString() error = %v", err)
+				}
+				data, err := f.Unflatten(flat)
+				if err != nil {
+					t.Fatalf("Unflatten() error = %v", err)
+				}
+				again, err := f.FlattenYAML(data)
+				if err != nil {
+					t.Fatalf("FlattenYAML() error = %v", err)
+				}
+				if !reflect.DeepEqual(again, flat) {
+					t.Errorf("round trip = %v, want %v", again, flat)
+				}
+				if len(flat) != 5 {
+					t.Errorf("expected 5 distinct keys, got %v", flat)
+				}
+			})
+		}
+	}
+
+	f := New()
+	f.KeyEscaping = "percent"
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+}
@@ -0,0 +1,30 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import "fmt"
+
+// Location is the position of a flattened value in its source. Line and Column start at 1.
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String renders the location as file:line:column, or line:column when the file is unknown
+func (l Location) String() string {
+	if l.File == "" {
+		return fmt.Sprintf("%d:%d", l.Line, l.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// withSourceFile returns a copy of the Flattener that records path as the file of every
+// location, unless SourceFile is already set
+func (f *Flattener) withSourceFile(path string) *Flattener {
+	if f.SourceFile != "" {
+		return f
+	}
+	clone := *f
+	clone.SourceFile = path
+	return &clone
+}
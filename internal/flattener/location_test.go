@@ -0,0 +1,101 @@
+package flattener
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFlattenLocations(t *testing.T) {
+	yamlStr := `server:
+  host: example.com
+  ports:
+    - 80
+    -   443
+defaults: &defaults
+  timeout: 30
+client:
+  <<: *defaults
+  name: "app"
+empty:
+`
+
+	result, err := New().FlattenString(yamlStr)
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+
+	expected := map[string]Location{
+		"server.host":      {Line: 2, Column: 9},
+		"server.ports[0]":  {Line: 4, Column: 7},
+		"server.ports[1]":  {Line: 5, Column: 9},
+		"defaults.timeout": {Line: 7, Column: 12},
+		"client.timeout":   {Line: 7, Column: 12},
+		"client.name":      {Line: 10, Column: 9},
+		"empty":            {Line: 11, Column: 7},
+	}
+	if !reflect.DeepEqual(result.Locations, expected) {
+		t.Errorf("Locations = %v, want %v", result.Locations, expected)
+	}
+}
+
+func TestFlattenLocationsWithTransformsAndDocuments(t *testing.T) {
+	f := New()
+	f.KeyTransforms = []KeyTransform{KeyTransformUpper}
+
+	result, err := f.FlattenDocumentsPrefixed("a: 1\n---\nb: 2\n", "")
+	if err != nil {
+		t.Fatalf("FlattenDocumentsPrefixed() error = %v", err)
+	}
+
+	expected := map[string]Location{
+		"[0].A": {Line: 1, Column: 4},
+		"[1].B": {Line: 3, Column: 4},
+	}
+	if !reflect.DeepEqual(result.Locations, expected) {
+		t.Errorf("Locations = %v, want %v", result.Locations, expected)
+	}
+}
+
+func TestFlattenFileLocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("key: value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().FlattenFile(path)
+	if err != nil {
+		t.Fatalf("FlattenFile() error = %v", err)
+	}
+	if got, want := result.Locations["key"].String(), path+":1:6"; got != want {
+		t.Errorf("location = %q, want %q", got, want)
+	}
+
+	result, err = New().FlattenFileFormat(path, "")
+	if err != nil {
+		t.Fatalf("FlattenFileFormat() error = %v", err)
+	}
+	if got, want := result.Locations["key"].File, path; got != want {
+		t.Errorf("location file = %q, want %q", got, want)
+	}
+}
+
+func TestFlattenLocationsOtherFormats(t *testing.T) {
+	result, err := New().FlattenFormat(`{"a": 1}`, FormatJSON)
+	if err != nil {
+		t.Fatalf("FlattenFormat() error = %v", err)
+	}
+	if len(result.Locations) != 0 {
+		t.Errorf("Locations = %v, want none", result.Locations)
+	}
+}
+
+func TestLocationString(t *testing.T) {
+	if got := (Location{Line: 3, Column: 5}).String(); got != "3:5" {
+		t.Errorf("String() = %q, want %q", got, "3:5")
+	}
+	if got := (Location{File: "a.yaml", Line: 3, Column: 5}).String(); got != "a.yaml:3:5" {
+		t.Errorf("String() = %q, want %q", got, "a.yaml:3:5")
+	}
+}
@@ -0,0 +1,159 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NonStringKeys controls how map keys that are not strings, such as the 80 of
+// ports: {80: http} or the true of true: yes, are turned into flattened key segments.
+// Mappings and sequences used as keys are always an error.
+type NonStringKeys string
+
+const (
+	// NonStringKeysCanonical writes ints, floats, bools, null and timestamps in the
+	// canonical form flattened values use: 0x50 becomes 80, null and ~ become null and
+	// timestamps are written as RFC 3339
+	NonStringKeysCanonical NonStringKeys = "canonical"
+	// NonStringKeysReject fails on the first non-string key
+	NonStringKeysReject NonStringKeys = "reject"
+	// NonStringKeysSkip leaves pairs with a non-string key out of the result
+	NonStringKeysSkip NonStringKeys = "skip"
+)
+
+// nullKey is the canonical form of a null map key
+const nullKey = "null"
+
+// canonicalKey returns the canonical form of a decoded scalar map key. It reports false
+// for keys that are not scalars.
+func canonicalKey(key interface{}) (string, bool) {
+	switch key.(type) {
+	case nil:
+		return nullKey, true
+	case string, int, int64, uint64, float64, bool, time.Time:
+		value, _ := scalarString(key)
+		return value, true
+	default:
+		return "", false
+	}
+}
+
+// mapKey returns the key segment of a decoded map key according to NonStringKeys and
+// reports whether the pair is kept. prefix is the path of the map.
+func (w *walker) mapKey(key interface{}, prefix string) (string, bool, error) {
+	if s, ok := key.(string); ok {
+		return s, true, nil
+	}
+	s, ok := canonicalKey(key)
+	if !ok {
+		return "", false, ParsingError(fmt.Sprintf("map key of type %T below %s is not supported, keys must be scalars", key, describePath(prefix)), nil).at(prefix)
+	}
+	return w.nonStringKey(s, prefix)
+}
+
+// nonStringKey applies NonStringKeys to a non-string key written as s
+func (w *walker) nonStringKey(s, prefix string) (string, bool, error) {
+	switch w.NonStringKeys {
+	case NonStringKeysReject:
+		return "", false, ParsingError(fmt.Sprintf("non-string key %s in YAML map", s), nil).at(w.joinKey(prefix, w.objectKey(s)))
+	case NonStringKeysSkip:
+		return "", false, nil
+	default:
+		return s, true, nil
+	}
+}
+
+// nodeKey returns the string key node for a scalar YAML key node according to
+// NonStringKeys, and reports whether the pair is kept. Non-string keys are copied with
+// their text in ScalarFormat as value so that the rest of the walker only sees string
+// keys.
+func (w *walker) nodeKey(key *yaml.Node, prefix string) (*yaml.Node, bool, error) {
+	if key.Kind != yaml.ScalarNode {
+		kind := "mapping"
+		if key.Kind == yaml.SequenceNode {
+			kind = "sequence"
+		}
+		return nil, false, ParsingError(fmt.Sprintf("%s used as a map key below %s is not supported, keys must be scalars", kind, describePath(prefix)), nil).
+			at(prefix).atLine(key.Line, key.Column)
+	}
+	if tag := w.scalarTag(key); tag == strTag || !strings.HasPrefix(tag, "!!") {
+		return key, true, nil
+	}
+
+	value, valueType, err := w.nodeScalar(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if valueType == ValueTypeNull && value == "" {
+		value = nullKey
+	}
+	s, kept, err := w.nonStringKey(value, prefix)
+	if err != nil {
+		var fe *Error
+		if errors.As(err, &fe) {
+			fe.atLine(key.Line, key.Column)
+		}
+		return nil, false, err
+	}
+	if !kept {
+		return nil, false, nil
+	}
+	converted := *key
+	converted.Tag, converted.Value, converted.Style = strTag, s, 0
+	return &converted, true, nil
+}
+
+// describePath names a key path in error messages
+func describePath(prefix string) string {
+	if prefix == "" {
+		return "the document root"
+	}
+	return fmt.Sprintf("%q", prefix)
+}
+
+// flattenInterfaceMapWithDepth flattens a map[interface{}]interface{} in sorted key order
+// with the given prefix and tracks depth. Non-string keys follow NonStringKeys.
+func (w *walker) flattenInterfaceMapWithDepth(m map[interface{}]interface{}, prefix string, depth int, included bool) error {
+	type entry struct {
+		key       string
+		value     interface{}
+		converted bool
+	}
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		key, kept, err := w.mapKey(k, prefix)
+		if err != nil {
+			return w.locate(err, prefix, nil)
+		}
+		if kept {
+			_, isString := k.(string)
+			entries = append(entries, entry{key: key, value: v, converted: !isString})
+		}
+	}
+	// a string key sorts before a converted key equal to it, so the order of a
+	// collision does not depend on map iteration
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].key != entries[j].key {
+			return entries[i].key < entries[j].key
+		}
+		return !entries[i].converted && entries[j].converted
+	})
+
+	if len(m) == 0 && included {
+		if _, err := w.setEmpty(prefix, false); err != nil {
+			return w.locate(err, prefix, nil)
+		}
+	}
+	for _, e := range entries {
+		if err := w.flattenValueWithDepth(e.value, w.joinKey(prefix, w.objectKey(e.key)), depth, included); err != nil {
+			return err
+		}
+	}
+	return nil
+}
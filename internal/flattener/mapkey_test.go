@@ -0,0 +1,107 @@
+package flattener
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenNonStringKeys(t *testing.T) {
+	yamlStr := `ports:
+  80: http
+  0x1bb: https
+flags:
+  true: "yes"
+  ~: none
+  1.5: ratio
+dates:
+  2026-01-02: release
+name: app
+`
+
+	tests := []struct {
+		policy   NonStringKeys
+		expected map[string]string
+		wantErr  string
+	}{
+		{
+			policy: NonStringKeysCanonical,
+			expected: map[string]string{
+				"ports.80":                   "http",
+				"ports.443":                  "https",
+				"flags.true":                 "yes",
+				"flags.null":                 "none",
+				"flags.1.5":                  "ratio",
+				"dates.2026-01-02T00:00:00Z": "release",
+				"name":                       "app",
+			},
+		},
+		{
+			policy:   NonStringKeysSkip,
+			expected: map[string]string{"name": "app"},
+		},
+		{
+			policy:  NonStringKeysReject,
+			wantErr: "ports.80",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			f := New()
+			f.NonStringKeys = tt.policy
+
+			flat, err := f.FlattenYAMLString(yamlStr)
+			if tt.wantErr != "" {
+				assertErrorType(t, err, ErrTypeParsing)
+				var fe *Error
+				if errors.As(err, &fe) && (fe.Path != tt.wantErr || fe.Line != 2) {
+					t.Errorf("expected error at %s on line 2, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FlattenYAMLString() error = %v", err)
+			}
+			if !reflect.DeepEqual(flat, tt.expected) {
+				t.Errorf("FlattenYAMLString() = %v, want %v", flat, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenComplexKeys(t *testing.T) {
+	for _, policy := range []NonStringKeys{NonStringKeysCanonical, NonStringKeysSkip} {
+		f := New()
+		f.NonStringKeys = policy
+
+		_, err := f.FlattenYAMLString("a:\n  b:\n    ? [x, y]\n    : value\n")
+		assertErrorType(t, err, ErrTypeParsing)
+		var fe *Error
+		if !errors.As(err, &fe) || fe.Path != "a.b" || fe.Line != 3 {
+			t.Errorf("expected error at a.b on line 3, got %v", err)
+		}
+		if !strings.Contains(err.Error(), `sequence used as a map key below "a.b"`) {
+			t.Errorf("expected error to describe the key, got %v", err)
+		}
+	}
+}
+
+func TestFlattenNonStringKeysCollide(t *testing.T) {
+	f := New()
+	_, err := f.FlattenYAMLString("80: number\n\"80\": string\n")
+	assertErrorType(t, err, ErrTypeValidation)
+
+	f.KeyCollisions = KeyCollisionsFirstWins
+	flat, err := f.FlattenYAML(map[interface{}]interface{}{80: "number", "80": "string", true: "bool"})
+	if err != nil {
+		t.Fatalf("FlattenYAML() error = %v", err)
+	}
+	if want := map[string]string{"80": "string", "true": "bool"}; !reflect.DeepEqual(flat, want) {
+		t.Errorf("FlattenYAML() = %v, want %v", flat, want)
+	}
+
+	f.NonStringKeys = "stringify"
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+}
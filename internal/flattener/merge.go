@@ -0,0 +1,351 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ArrayMerge controls how an array in a later layer is combined with the same array in
+// an earlier layer
+type ArrayMerge string
+
+const (
+	// ArrayMergeReplace replaces the earlier array, as Helm does with -f
+	ArrayMergeReplace ArrayMerge = "replace"
+	// ArrayMergeAppend appends the items of the later array to the earlier one
+	ArrayMergeAppend ArrayMerge = "append"
+	// ArrayMergeByIndex merges items at the same position and appends extra items
+	ArrayMergeByIndex ArrayMerge = "merge_by_index"
+	// ArrayMergeByKey merges object items whose ArrayMergeKey field has the same value
+	// and appends the others
+	ArrayMergeByKey ArrayMerge = "merge_by_key"
+)
+
+// Layer is one YAML document of a layered merge, such as one values file passed to
+// Helm with -f
+type Layer struct {
+	// Name identifies the layer in Result.Sources, locations and errors, e.g. its path
+	Name    string
+	Content string
+}
+
+// FlattenLayers deep-merges YAML documents in order and flattens the result. Objects
+// are merged key by key, later layers win for scalars, a null in a later layer deletes
+// the key as in Helm values, and arrays are combined as selected by ArrayMerge.
+// Result.Sources names the layer every value came from. Empty layers are skipped, and
+// MaxYAMLSize applies to each layer.
+func (f *Flattener) FlattenLayers(layers []Layer) (*Result, error) {
+	return f.FlattenLayersContext(context.Background(), layers)
+}
+
+// FlattenLayersContext is FlattenLayers with a context that stops parsing, merging and
+// flattening when it is done. Timeout applies to all layers together.
+func (f *Flattener) FlattenLayersContext(ctx context.Context, layers []Layer) (*Result, error) {
+	if len(layers) == 0 {
+		return nil, ValidationError("at least one layer is required", nil)
+	}
+
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	w, err := f.newWalker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.origin = make(map[*yaml.Node]int)
+
+	var merged *yaml.Node
+	for _, layer := range layers {
+		doc, err := f.decodeLayer(ctx, layer)
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+
+		w.index(doc.Content[0], len(w.layers))
+		w.layers = append(w.layers, layer.Name)
+		if merged == nil {
+			merged = doc.Content[0]
+			continue
+		}
+		if merged, err = w.mergeNodes(merged, doc.Content[0], "", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if merged == nil {
+		return nil, ValidationError("no layer contains data", nil)
+	}
+
+	if err := w.flattenValueWithDepth(merged, "", 0, w.filter.includeAll()); err != nil {
+		return nil, err
+	}
+	return w.finish()
+}
+
+// FlattenFiles reads YAML files and flattens them as layers of FlattenLayers, in order,
+// naming each layer by its path. Every file gets the path and size checks of
+// FlattenYAMLFile.
+func (f *Flattener) FlattenFiles(paths []string) (*Result, error) {
+	return f.FlattenFilesContext(context.Background(), paths)
+}
+
+// FlattenFilesContext is FlattenFiles with a context that stops parsing, merging and
+// flattening when it is done
+func (f *Flattener) FlattenFilesContext(ctx context.Context, paths []string) (*Result, error) {
+	layers := make([]Layer, len(paths))
+	for i, path := range paths {
+		content, err := f.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = Layer{Name: path, Content: content}
+	}
+	return f.FlattenLayersContext(ctx, layers)
+}
+
+// decodeLayer decodes the document of one layer, or returns nil for an empty layer
+func (f *Flattener) decodeLayer(ctx context.Context, layer Layer) (*yaml.Node, error) {
+	if strings.TrimSpace(layer.Content) == "" {
+		return nil, nil
+	}
+	content, err := f.prepareContent(layer.Content, "YAML")
+	if err != nil {
+		return nil, inFile(err, layer.Name)
+	}
+
+	data, err := decodeYAML(ctx, content)
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, "YAML parsing")
+	}
+	if err != nil {
+		return nil, inFile(err, layer.Name)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return data.(*yaml.Node), nil
+}
+
+// index records layer as the origin of node and every node below it
+func (w *walker) index(node *yaml.Node, layer int) {
+	if _, ok := w.origin[node]; ok {
+		return
+	}
+	w.origin[node] = layer
+	for _, child := range node.Content {
+		w.index(child, layer)
+	}
+}
+
+// fileOf returns the name of the layer node was read from, or SourceFile
+func (w *walker) fileOf(node *yaml.Node) string {
+	if layer, ok := w.origin[node]; ok {
+		return w.layers[layer]
+	}
+	return w.SourceFile
+}
+
+// mergeNodes deep-merges overlay onto base at prefix. Mappings are merged key by key,
+// keeping the order of base and adding new keys of overlay at the end; sequences are
+// combined according to ArrayMerge; anything else is replaced by overlay. The inputs
+// are not modified.
+func (w *walker) mergeNodes(base, overlay *yaml.Node, prefix string, depth int) (*yaml.Node, error) {
+	if err := w.checkContext("merging"); err != nil {
+		return nil, w.locate(err, prefix, overlay)
+	}
+	if depth > w.MaxNestingDepth {
+		return nil, w.locate(DepthLimitError(w.MaxNestingDepth), prefix, overlay)
+	}
+	for _, node := range []*yaml.Node{base, overlay} {
+		if err := w.checkAlias(node); err != nil {
+			return nil, w.locate(err, prefix, node)
+		}
+	}
+
+	b, o := resolveAlias(base), resolveAlias(overlay)
+	switch {
+	case b.Kind == yaml.MappingNode && o.Kind == yaml.MappingNode:
+		return w.mergeMappings(b, o, aliasName(base), aliasName(overlay), prefix, depth)
+	case b.Kind == yaml.SequenceNode && o.Kind == yaml.SequenceNode:
+		return w.mergeSequences(b, o, aliasName(base), aliasName(overlay), prefix, depth)
+	default:
+		return overlay, nil
+	}
+}
+
+// mergeMappings merges the pairs of overlay onto those of base, after resolving merge
+// keys. A null in overlay deletes the key, as in Helm values. baseAnchor and
+// overlayAnchor name the anchors the mappings were copied from, if any, so that their
+// pairs keep reporting it in Result.Anchors.
+func (w *walker) mergeMappings(base, overlay *yaml.Node, baseAnchor, overlayAnchor, prefix string, depth int) (*yaml.Node, error) {
+	basePairs, err := w.mappingPairs(base, prefix)
+	if err != nil {
+		return nil, w.locate(err, prefix, base)
+	}
+	overlayPairs, err := w.mappingPairs(overlay, prefix)
+	if err != nil {
+		return nil, w.locate(err, prefix, overlay)
+	}
+
+	pairs := make([]nodePair, 0, len(basePairs)+len(overlayPairs))
+	positions := make(map[string]int, len(basePairs)+len(overlayPairs))
+	for _, pair := range basePairs {
+		if pair.anchor == "" {
+			pair.anchor = baseAnchor
+		}
+		positions[pair.identity] = len(pairs)
+		pairs = append(pairs, pair)
+	}
+	deleted := make(map[int]bool)
+	for _, pair := range overlayPairs {
+		if pair.anchor == "" {
+			pair.anchor = overlayAnchor
+		}
+		position, ok := positions[pair.identity]
+		if w.isNull(pair.value) {
+			if ok {
+				deleted[position] = true
+			}
+			continue
+		}
+		if !ok {
+			positions[pair.identity] = len(pairs)
+			pairs = append(pairs, pair)
+			continue
+		}
+		key := w.joinKey(prefix, w.objectKey(pair.key.Value))
+		value, err := w.mergeNodes(w.aliased(pairs[position].value, pairs[position].anchor), w.aliased(pair.value, pair.anchor), key, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		// the merged value carries the anchors of both sides itself
+		pairs[position].value, pairs[position].anchor = value, ""
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: base.Line, Column: base.Column}
+	for i, pair := range pairs {
+		if !deleted[i] {
+			merged.Content = append(merged.Content, pair.original, w.aliased(pair.value, pair.anchor))
+		}
+	}
+	return merged, nil
+}
+
+// mergeSequences combines two sequences according to ArrayMerge. baseAnchor and
+// overlayAnchor are the anchors the sequences were copied from, as for mergeMappings.
+func (w *walker) mergeSequences(base, overlay *yaml.Node, baseAnchor, overlayAnchor, prefix string, depth int) (*yaml.Node, error) {
+	if w.ArrayMerge == ArrayMergeReplace {
+		return w.aliased(overlay, overlayAnchor), nil
+	}
+
+	merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: base.Line, Column: base.Column}
+	for _, item := range base.Content {
+		merged.Content = append(merged.Content, w.aliased(item, baseAnchor))
+	}
+
+	switch w.ArrayMerge {
+	case ArrayMergeAppend:
+		for _, item := range overlay.Content {
+			merged.Content = append(merged.Content, w.aliased(item, overlayAnchor))
+		}
+	case ArrayMergeByIndex:
+		for i, item := range overlay.Content {
+			item = w.aliased(item, overlayAnchor)
+			if i >= len(merged.Content) {
+				merged.Content = append(merged.Content, item)
+				continue
+			}
+			value, err := w.mergeNodes(merged.Content[i], item, w.indexKey(prefix, i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			merged.Content[i] = value
+		}
+	case ArrayMergeByKey:
+		positions := make(map[string]int, len(base.Content))
+		for i, item := range base.Content {
+			id, err := w.itemKey(item, w.indexKey(prefix, i))
+			if err != nil {
+				return nil, err
+			}
+			positions[id] = i
+		}
+		for i, item := range overlay.Content {
+			id, err := w.itemKey(item, w.indexKey(prefix, i))
+			if err != nil {
+				return nil, err
+			}
+			item = w.aliased(item, overlayAnchor)
+			position, ok := positions[id]
+			if !ok {
+				positions[id] = len(merged.Content)
+				merged.Content = append(merged.Content, item)
+				continue
+			}
+			value, err := w.mergeNodes(merged.Content[position], item, w.indexKey(prefix, position), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			merged.Content[position] = value
+		}
+	}
+	return merged, nil
+}
+
+// isNull reports whether a value node is a null scalar
+func (w *walker) isNull(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	return node.Kind == yaml.ScalarNode && w.scalarTag(node) == nullTag
+}
+
+// aliasName returns the name of the innermost alias node resolves through, or "" when
+// it is not an alias
+func aliasName(node *yaml.Node) string {
+	name := ""
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		name = node.Value
+		node = node.Alias
+	}
+	return name
+}
+
+// aliased returns node as copied from anchor: wrapped in an alias node naming the
+// anchor, so that walking it records the anchor like any alias. Nodes that are already
+// aliases, and nodes not copied from an anchor, are returned as they are.
+func (w *walker) aliased(node *yaml.Node, anchor string) *yaml.Node {
+	if anchor == "" || node.Kind == yaml.AliasNode {
+		return node
+	}
+	alias := &yaml.Node{Kind: yaml.AliasNode, Value: anchor, Alias: node, Line: node.Line, Column: node.Column}
+	if layer, ok := w.origin[node]; ok {
+		w.origin[alias] = layer
+	}
+	return alias
+}
+
+// itemKey returns the ArrayMergeKey field of an array item at prefix
+func (w *walker) itemKey(item *yaml.Node, prefix string) (string, error) {
+	if err := w.checkAlias(item); err != nil {
+		return "", w.locate(err, prefix, item)
+	}
+	resolved := resolveAlias(item)
+	if resolved.Kind == yaml.MappingNode {
+		pairs, err := w.mappingPairs(resolved, prefix)
+		if err != nil {
+			return "", w.locate(err, prefix, resolved)
+		}
+		for _, pair := range pairs {
+			if value := resolveAlias(pair.value); pair.key.Value == w.ArrayMergeKey && value.Kind == yaml.ScalarNode {
+				return value.Value, nil
+			}
+		}
+	}
+	return "", w.locate(ValidationError(fmt.Sprintf("array item has no %q field to merge by", w.ArrayMergeKey), nil), prefix, item)
+}
@@ -0,0 +1,258 @@
+package flattener
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenLayers(t *testing.T) {
+	layers := []Layer{
+		{Name: "base.yaml", Content: "image:\n  repository: app\n  tag: latest\nreplicas: 1\nresources:\n  limits:\n    cpu: 100m\n"},
+		{Name: "env/prod.yaml", Content: "image:\n  tag: v1.2.3\nreplicas: 3\n"},
+		{Name: "region/eu.yaml", Content: "resources:\n  limits:\n    memory: 1Gi\nregion: eu-west-1\n"},
+	}
+
+	result, err := New().FlattenLayers(layers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedKeys := []string{"image.repository", "image.tag", "replicas", "resources.limits.cpu", "resources.limits.memory", "region"}
+	if !reflect.DeepEqual(result.Keys, expectedKeys) {
+		t.Errorf("expected keys %v, got %v", expectedKeys, result.Keys)
+	}
+	expectedSources := map[string]string{
+		"image.repository":        "base.yaml",
+		"image.tag":               "env/prod.yaml",
+		"replicas":                "env/prod.yaml",
+		"resources.limits.cpu":    "base.yaml",
+		"resources.limits.memory": "region/eu.yaml",
+		"region":                  "region/eu.yaml",
+	}
+	if !reflect.DeepEqual(result.Sources, expectedSources) {
+		t.Errorf("expected sources %v, got %v", expectedSources, result.Sources)
+	}
+	if result.Values["image.tag"] != "v1.2.3" || result.Types["replicas"] != ValueTypeNumber {
+		t.Errorf("expected later layers to win, got %v", result.Values)
+	}
+	if location := result.Locations["image.tag"]; location != (Location{File: "env/prod.yaml", Line: 2, Column: 8}) {
+		t.Errorf("expected image.tag located in env/prod.yaml, got %v", location)
+	}
+}
+
+func TestFlattenLayersArrayMerge(t *testing.T) {
+	ports := []Layer{{Content: "ports: [80, 443]\n"}, {Content: "ports: [8080]\n"}}
+	users := []Layer{
+		{Content: "users:\n  - name: alice\n    role: admin\n  - name: bob\n    role: dev\n"},
+		{Content: "users:\n  - name: bob\n    role: ops\n  - name: carol\n"},
+	}
+
+	tests := []struct {
+		name     string
+		merge    ArrayMerge
+		layers   []Layer
+		expected map[string]string
+	}{
+		{
+			name:     "replace",
+			merge:    ArrayMergeReplace,
+			layers:   ports,
+			expected: map[string]string{"ports[0]": "8080"},
+		},
+		{
+			name:     "append",
+			merge:    ArrayMergeAppend,
+			layers:   ports,
+			expected: map[string]string{"ports[0]": "80", "ports[1]": "443", "ports[2]": "8080"},
+		},
+		{
+			name:     "merge by index",
+			merge:    ArrayMergeByIndex,
+			layers:   ports,
+			expected: map[string]string{"ports[0]": "8080", "ports[1]": "443"},
+		},
+		{
+			name:   "merge objects by index",
+			merge:  ArrayMergeByIndex,
+			layers: users,
+			expected: map[string]string{
+				"users[0].name": "bob", "users[0].role": "ops",
+				"users[1].name": "carol", "users[1].role": "dev",
+			},
+		},
+		{
+			name:   "merge by key",
+			merge:  ArrayMergeByKey,
+			layers: users,
+			expected: map[string]string{
+				"users[0].name": "alice", "users[0].role": "admin",
+				"users[1].name": "bob", "users[1].role": "ops",
+				"users[2].name": "carol",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.ArrayMerge = tt.merge
+			f.ArrayMergeKey = "name"
+
+			result, err := f.FlattenLayers(tt.layers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result.Values)
+			}
+		})
+	}
+}
+
+func TestFlattenLayersAliasesAndMergeKeys(t *testing.T) {
+	base := "defaults: &defaults\n  timeout: 30\n  retries: 3\nservice:\n  <<: *defaults\n  name: api\n"
+	overlay := "service:\n  timeout: 60\n"
+
+	result, err := New().FlattenLayers([]Layer{{Name: "base", Content: base}, {Name: "prod", Content: overlay}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"defaults.timeout": "30", "defaults.retries": "3",
+		"service.timeout": "60", "service.retries": "3", "service.name": "api",
+	}
+	if !reflect.DeepEqual(result.Values, expected) {
+		t.Errorf("expected %v, got %v", expected, result.Values)
+	}
+	if result.Sources["service.timeout"] != "prod" || result.Sources["service.retries"] != "base" {
+		t.Errorf("unexpected sources %v", result.Sources)
+	}
+	expectedAnchors := map[string]string{"service.retries": "defaults"}
+	if !reflect.DeepEqual(result.Anchors, expectedAnchors) {
+		t.Errorf("expected anchors %v, got %v", expectedAnchors, result.Anchors)
+	}
+}
+
+func TestFlattenLayersNullDeletes(t *testing.T) {
+	base := "resources:\n  limits:\n    cpu: 100m\n    memory: 1Gi\nnodeSelector:\n  zone: a\n"
+	overlay := "resources:\n  limits:\n    cpu: ~\nnodeSelector: null\nextra: null\n"
+
+	result, err := New().FlattenLayers([]Layer{{Name: "base", Content: base}, {Name: "prod", Content: overlay}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"resources.limits.memory": "1Gi"}
+	if !reflect.DeepEqual(result.Values, expected) {
+		t.Errorf("expected %v, got %v", expected, result.Values)
+	}
+}
+
+func TestFlattenLayersNonStringKeys(t *testing.T) {
+	base := "ports:\n  80: http\n"
+	overlay := "ports:\n  \"80\": web\n"
+
+	_, err := New().FlattenLayers([]Layer{{Content: base}, {Content: overlay}})
+	assertErrorType(t, err, ErrTypeValidation)
+
+	f := New()
+	f.KeyCollisions = KeyCollisionsFirstWins
+	result, err := f.FlattenLayers([]Layer{{Content: base}, {Content: overlay}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Values["ports.80"] != "http" {
+		t.Errorf("expected 80 and \"80\" to stay separate keys, got %v", result.Values)
+	}
+}
+
+func TestFlattenLayersErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(f *Flattener)
+		layers  []Layer
+		errType ErrorType
+		message string
+	}{
+		{
+			name:    "no layers",
+			layers:  nil,
+			errType: ErrTypeValidation,
+			message: "at least one layer is required",
+		},
+		{
+			name:    "only empty layers",
+			layers:  []Layer{{Content: ""}, {Content: "# comment only\n"}},
+			errType: ErrTypeValidation,
+			message: "no layer contains data",
+		},
+		{
+			name:    "invalid layer",
+			layers:  []Layer{{Name: "base.yaml", Content: "a: 1\n"}, {Name: "bad.yaml", Content: "a: [\n"}},
+			errType: ErrTypeParsing,
+			message: "bad.yaml",
+		},
+		{
+			name:    "duplicate key in layer",
+			layers:  []Layer{{Name: "base.yaml", Content: "a:\n  b: 1\n"}, {Name: "prod.yaml", Content: "a:\n  b: 2\n  b: 3\n"}},
+			errType: ErrTypeParsing,
+			message: "duplicate mapping key \"b\" (first defined on line 2) at a.b (prod.yaml:3:3)",
+		},
+		{
+			name:    "merge key missing",
+			modify:  func(f *Flattener) { f.ArrayMerge, f.ArrayMergeKey = ArrayMergeByKey, "name" },
+			layers:  []Layer{{Content: "users:\n  - name: a\n"}, {Name: "prod.yaml", Content: "users:\n  - role: b\n"}},
+			errType: ErrTypeValidation,
+			message: "array item has no \"name\" field to merge by at users[0] (prod.yaml:2:5)",
+		},
+		{
+			name:    "rejected alias",
+			modify:  func(f *Flattener) { f.RejectAliases = true },
+			layers:  []Layer{{Content: "a: &x {b: 1}\nc: *x\n"}, {Content: "c: {d: 2}\n"}},
+			errType: ErrTypeAlias,
+			message: "alias *x is not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			if tt.modify != nil {
+				tt.modify(f)
+			}
+
+			_, err := f.FlattenLayers(tt.layers)
+			assertErrorType(t, err, tt.errType)
+			if err != nil && !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestFlattenFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	if err := os.WriteFile(base, []byte("replicas: 1\nimage: app\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(prod, []byte("replicas: 3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().FlattenFiles([]string{base, prod})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Values["replicas"] != "3" || result.Sources["replicas"] != prod || result.Sources["image"] != base {
+		t.Errorf("unexpected result %v with sources %v", result.Values, result.Sources)
+	}
+
+	_, err = New().FlattenFiles([]string{base, filepath.Join(dir, "missing.yaml")})
+	assertErrorType(t, err, ErrTypeFileAccess)
+}
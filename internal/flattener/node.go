@@ -0,0 +1,319 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodePair is a key and value of a YAML mapping
+type nodePair struct {
+	key   *yaml.Node
+	value *yaml.Node
+	// original is the key node as written, before non-string keys are converted
+	original *yaml.Node
+	// identity tells keys apart by tag and value, so 80 and "80" are different keys
+	identity string
+	// anchor names the anchor a merged pair was copied from, if any
+	anchor string
+}
+
+// flattenNodeWithDepth flattens a YAML node in source order. The depth, size and filter
+// checks for prefix have already been done by flattenValueWithDepth.
+func (w *walker) flattenNodeWithDepth(node *yaml.Node, prefix string, depth int, included bool) error {
+	if w.alias != "" {
+		if err := w.expand(1); err != nil {
+			return w.locate(err, prefix, node)
+		}
+	}
+
+	if node.Anchor != "" {
+		if w.anchors[node] {
+			return w.locate(ParsingError(fmt.Sprintf("anchor %q value contains itself", node.Anchor), nil), prefix, node)
+		}
+		w.anchors[node] = true
+		defer delete(w.anchors, node)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return w.flattenNodeWithDepth(node.Content[0], prefix, depth, included)
+	case yaml.AliasNode:
+		if w.RejectAliases {
+			return w.locate(AliasRejectedError(node.Value), prefix, node)
+		}
+		return w.withAlias(node.Value, func() error {
+			return w.flattenNodeWithDepth(node.Alias, prefix, depth, included)
+		})
+	case yaml.MappingNode:
+		pairs, err := w.mappingPairs(node, prefix)
+		if err != nil {
+			return w.locate(err, prefix, node)
+		}
+		if len(pairs) == 0 && included {
+			if err := w.setEmptyNode(prefix, node, false); err != nil {
+				return err
+			}
+		}
+		for _, pair := range pairs {
+			key := w.joinKey(prefix, w.objectKey(pair.key.Value))
+			err := w.withAlias(pair.anchor, func() error {
+				return w.flattenValueWithDepth(pair.value, key, depth+1, included)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 && included {
+			if err := w.setEmptyNode(prefix, node, true); err != nil {
+				return err
+			}
+		}
+		for i, item := range node.Content {
+			if err := w.flattenValueWithDepth(item, w.indexKey(prefix, i), depth+1, included); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if w.ExpandEncoded && w.scalarTag(node) == strTag {
+		if expanded, err := w.expandEncoded(node.Value, prefix, depth, included, node); expanded || err != nil {
+			return err
+		}
+	}
+
+	if !included {
+		return nil
+	}
+
+	value, valueType, err := w.nodeScalar(node)
+	if err != nil {
+		return w.locate(err, prefix, node)
+	}
+	stored, err := w.store(prefix, value, valueType)
+	if err != nil {
+		return w.locate(err, prefix, node)
+	}
+	if stored {
+		w.recordNode(prefix, node)
+	}
+	return nil
+}
+
+// setEmptyNode stores the empty mapping or sequence node at key as setEmpty does
+func (w *walker) setEmptyNode(key string, node *yaml.Node, isArray bool) error {
+	stored, err := w.setEmpty(key, isArray)
+	if err != nil {
+		return w.locate(err, key, node)
+	}
+	if stored {
+		w.recordNode(key, node)
+	}
+	return nil
+}
+
+// recordNode records the location, layer and anchor of the node the value at key was
+// read from
+func (w *walker) recordNode(key string, node *yaml.Node) {
+	w.result.Locations[key] = Location{File: w.fileOf(node), Line: node.Line, Column: node.Column}
+	if layer, ok := w.origin[node]; ok {
+		w.result.Sources[key] = w.layers[layer]
+	}
+	if w.alias != "" {
+		w.result.Anchors[key] = w.alias
+	}
+}
+
+// withAlias runs walk while copying from the named anchor. Nested aliases replace the
+// anchor name, so keys report the closest anchor they were copied from.
+func (w *walker) withAlias(anchor string, walk func() error) error {
+	if anchor == "" {
+		return walk()
+	}
+	previous := w.alias
+	w.alias = anchor
+	defer func() { w.alias = previous }()
+	return walk()
+}
+
+// expand counts n nodes or merged keys walked through aliases against MaxAliasExpansions
+func (w *walker) expand(n int) error {
+	w.expansions += n
+	if w.expansions > w.MaxAliasExpansions {
+		return AliasLimitError(w.MaxAliasExpansions)
+	}
+	return nil
+}
+
+// checkAlias refuses an alias node when RejectAliases is set
+func (w *walker) checkAlias(node *yaml.Node) error {
+	if w.RejectAliases && node.Kind == yaml.AliasNode {
+		return AliasRejectedError(node.Value).atLine(node.Line, node.Column)
+	}
+	return nil
+}
+
+// mappingPairs returns the key/value pairs of the mapping at prefix in source order, with the pairs
+// of merge keys (<<) inserted where the merge key appears. Keys defined in the mapping
+// itself take precedence over merged keys, and earlier merged mappings over later ones.
+func (w *walker) mappingPairs(node *yaml.Node, prefix string) ([]nodePair, error) {
+	// keys holds the key of every pair, with a nil key for merge keys and skipped keys
+	keys := make([]nodePair, len(node.Content)/2)
+	explicit := make(map[string]bool, len(node.Content)/2)
+	duplicates := make(map[string]*yaml.Node, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if err := w.checkAlias(node.Content[i]); err != nil {
+			return nil, err
+		}
+		original := resolveAlias(node.Content[i])
+		if original.ShortTag() == mergeTag {
+			continue
+		}
+		key, kept, err := w.nodeKey(original, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if !kept {
+			continue
+		}
+		// keys are duplicates when they have the same tag and value, so 80 and "80" are
+		// different keys that collide after flattening
+		identity := w.scalarTag(original) + "\x00" + key.Value
+		if previous, ok := duplicates[identity]; ok {
+			return nil, ParsingError(fmt.Sprintf("duplicate mapping key %q (first defined on line %d)", key.Value, previous.Line), nil).
+				at(w.joinKey(prefix, w.objectKey(key.Value))).atLine(key.Line, key.Column)
+		}
+		duplicates[identity] = key
+		explicit[identity] = true
+		keys[i/2] = nodePair{key: key, original: original, identity: identity}
+	}
+
+	pairs := make([]nodePair, 0, len(node.Content)/2)
+	seen := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := resolveAlias(node.Content[i]), node.Content[i+1]
+		if key.ShortTag() != mergeTag {
+			if pair := keys[i/2]; pair.key != nil {
+				pair.value = value
+				pairs = append(pairs, pair)
+				seen[pair.identity] = true
+			}
+			continue
+		}
+
+		merged, err := w.mergedPairs(value, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range merged {
+			if explicit[pair.identity] || seen[pair.identity] {
+				continue
+			}
+			pairs = append(pairs, pair)
+			seen[pair.identity] = true
+		}
+	}
+
+	return pairs, nil
+}
+
+// mergedPairs returns the pairs merged in by the value of a merge key: a mapping, an
+// alias to a mapping, or a sequence of those. Pairs merged through an alias count
+// against MaxAliasExpansions and remember the anchor they were copied from.
+func (w *walker) mergedPairs(value *yaml.Node, prefix string) ([]nodePair, error) {
+	if err := w.checkAlias(value); err != nil {
+		return nil, err
+	}
+	anchor := ""
+	if value.Kind == yaml.AliasNode {
+		anchor = value.Value
+	}
+
+	value = resolveAlias(value)
+	switch value.Kind {
+	case yaml.MappingNode:
+		if w.anchors[value] {
+			return nil, ParsingError(fmt.Sprintf("anchor %q value contains itself", value.Anchor), nil).atLine(value.Line, value.Column)
+		}
+		w.anchors[value] = true
+		defer delete(w.anchors, value)
+
+		pairs, err := w.mappingPairs(value, prefix)
+		if err != nil || anchor == "" {
+			return pairs, err
+		}
+		if err := w.expand(len(pairs)); err != nil {
+			return nil, err
+		}
+		for i := range pairs {
+			if pairs[i].anchor == "" {
+				pairs[i].anchor = anchor
+			}
+		}
+		return pairs, nil
+	case yaml.SequenceNode:
+		var pairs []nodePair
+		for _, item := range value.Content {
+			if resolveAlias(item).Kind != yaml.MappingNode {
+				return nil, ParsingError("map merge requires map or sequence of maps as the value", nil).atLine(item.Line, item.Column)
+			}
+			merged, err := w.mergedPairs(item, prefix)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, merged...)
+		}
+		return pairs, nil
+	default:
+		return nil, ParsingError("map merge requires map or sequence of maps as the value", nil).atLine(value.Line, value.Column)
+	}
+}
+
+// YAML tags the walker handles without decoding
+const (
+	strTag   = "!!str"
+	nullTag  = "!!null"
+	mergeTag = "!!merge"
+)
+
+// scalarValue decodes a scalar node into the Go value yaml.Unmarshal would produce
+func scalarValue(node *yaml.Node) (interface{}, error) {
+	switch node.ShortTag() {
+	case strTag:
+		return node.Value, nil
+	case nullTag:
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, ParsingError(fmt.Sprintf("invalid value %q", node.Value), err).atLine(node.Line, node.Column)
+	}
+	return value, nil
+}
+
+// resolveAlias follows alias nodes to the node they refer to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// isEmptyDocument reports whether a decoded document has no content or only a null value
+func isEmptyDocument(doc *yaml.Node) bool {
+	if doc.Kind == 0 {
+		return true
+	}
+	if doc.Kind == yaml.DocumentNode {
+		return len(doc.Content) == 0 || isEmptyDocument(doc.Content[0])
+	}
+	return doc.Kind == yaml.ScalarNode && doc.ShortTag() == nullTag
+}
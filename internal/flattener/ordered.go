@@ -0,0 +1,41 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+// KeyValue is a flattened key with its value
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Ordered returns the flattened values in document order
+func (r *Result) Ordered() []KeyValue {
+	pairs := make([]KeyValue, len(r.Keys))
+	for i, k := range r.Keys {
+		pairs[i] = KeyValue{Key: k, Value: r.Values[k]}
+	}
+	return pairs
+}
+
+// orderedMap is a decoded object that keeps the order in which its keys appeared.
+// Decoders return it instead of a Go map so that the walker can follow source order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+// set stores a value, keeping the position of a key that is set again
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) get(key string) (interface{}, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
@@ -0,0 +1,160 @@
+package flattener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenSourceOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		content  string
+		expected []KeyValue
+	}{
+		{
+			name:   "YAML mappings and sequences",
+			format: FormatYAML,
+			content: `
+zeta: 1
+alpha:
+  second: b
+  first: a
+items:
+  - z
+  - a
+middle: true
+`,
+			expected: []KeyValue{
+				{Key: "zeta", Value: "1"},
+				{Key: "alpha.second", Value: "b"},
+				{Key: "alpha.first", Value: "a"},
+				{Key: "items[0]", Value: "z"},
+				{Key: "items[1]", Value: "a"},
+				{Key: "middle", Value: "true"},
+			},
+		},
+		{
+			name:   "YAML merge keys inserted in place",
+			format: FormatYAML,
+			content: `
+defaults: &defaults
+  timeout: 30
+  retries: 3
+service:
+  name: api
+  <<: *defaults
+  retries: 5
+`,
+			expected: []KeyValue{
+				{Key: "defaults.timeout", Value: "30"},
+				{Key: "defaults.retries", Value: "3"},
+				{Key: "service.name", Value: "api"},
+				{Key: "service.timeout", Value: "30"},
+				{Key: "service.retries", Value: "5"},
+			},
+		},
+		{
+			name:   "YAML aliases",
+			format: FormatYAML,
+			content: `
+b: &shared
+  y: 2
+  x: 1
+a: *shared
+`,
+			expected: []KeyValue{
+				{Key: "b.y", Value: "2"},
+				{Key: "b.x", Value: "1"},
+				{Key: "a.y", Value: "2"},
+				{Key: "a.x", Value: "1"},
+			},
+		},
+		{
+			name:    "JSON objects",
+			format:  FormatJSON,
+			content: `{"z": {"b": 1, "a": [true, null]}, "c": "x", "z2": 1}`,
+			expected: []KeyValue{
+				{Key: "z.b", Value: "1"},
+				{Key: "z.a[0]", Value: "true"},
+				{Key: "z.a[1]", Value: ""},
+				{Key: "c", Value: "x"},
+				{Key: "z2", Value: "1"},
+			},
+		},
+		{
+			name:    "JSON repeated key keeps first position",
+			format:  FormatJSON,
+			content: `{"b": 1, "a": 2, "b": 3}`,
+			expected: []KeyValue{
+				{Key: "b", Value: "3"},
+				{Key: "a", Value: "2"},
+			},
+		},
+		{
+			name:    "INI sections",
+			format:  FormatINI,
+			content: "name = app\n[server]\nport = 80\nhost = web\n[client]\nretries = 3\n[server]\ntls = on\n",
+			expected: []KeyValue{
+				{Key: "name", Value: "app"},
+				{Key: "server.port", Value: "80"},
+				{Key: "server.host", Value: "web"},
+				{Key: "server.tls", Value: "on"},
+				{Key: "client.retries", Value: "3"},
+			},
+		},
+		{
+			name:    "Properties",
+			format:  FormatProperties,
+			content: "z=1\na=2\nz=3\n",
+			expected: []KeyValue{
+				{Key: "z", Value: "3"},
+				{Key: "a", Value: "2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().FlattenFormat(tt.content, tt.format)
+			if err != nil {
+				t.Fatalf("FlattenFormat() error = %v", err)
+			}
+			if got := result.Ordered(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Ordered() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenSourceOrderWithTransforms(t *testing.T) {
+	f := New()
+	f.KeyTransforms = []KeyTransform{KeyTransformUpper}
+
+	result, err := f.FlattenString("b: 1\na: 2\n")
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+	if want := []string{"B", "A"}; !reflect.DeepEqual(result.Keys, want) {
+		t.Errorf("Keys = %v, want %v", result.Keys, want)
+	}
+}
+
+func TestFlattenYAMLNodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yamlStr string
+	}{
+		{name: "Duplicate key", yamlStr: "a: 1\nb: 2\na: 3\n"},
+		{name: "Merge of a scalar", yamlStr: "a:\n  <<: 1\n"},
+		{name: "Duplicate non-string key", yamlStr: "0x10: a\n16: b\n"},
+		{name: "Complex key", yamlStr: "? [a, b]\n: value\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().FlattenYAMLString(tt.yamlStr)
+			assertErrorType(t, err, ErrTypeParsing)
+		})
+	}
+}
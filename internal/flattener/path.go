@@ -0,0 +1,158 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy controls which symbolic links a file path may go through
+type SymlinkPolicy string
+
+const (
+	// SymlinkFollow follows every symbolic link, wherever it points. With
+	// AllowedBaseDirs set it acts as SymlinkWithinBase, so that links cannot escape the
+	// allow-list.
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkWithinBase follows symbolic links whose target is inside AllowedBaseDirs
+	SymlinkWithinBase SymlinkPolicy = "within_base"
+	// SymlinkReject refuses paths that go through any symbolic link below the base
+	// directory, or anywhere when AllowedBaseDirs is empty
+	SymlinkReject SymlinkPolicy = "reject"
+)
+
+// Path rules name the check that rejected a file path in Error.Rule
+const (
+	// PathRuleTraversal rejects paths containing ".."
+	PathRuleTraversal = "traversal"
+	// PathRuleBaseDir rejects paths outside AllowedBaseDirs
+	PathRuleBaseDir = "allowed_base_dirs"
+	// PathRuleSymlink rejects symbolic links refused by SymlinkPolicy
+	PathRuleSymlink = "symlink"
+)
+
+// validatePathPolicy checks AllowedBaseDirs and SymlinkPolicy
+func (f *Flattener) validatePathPolicy() error {
+	for _, dir := range f.AllowedBaseDirs {
+		if dir == "" {
+			return ValidationError("allowed base directory cannot be empty", nil)
+		}
+	}
+
+	switch f.SymlinkPolicy {
+	case SymlinkFollow, SymlinkReject:
+	case SymlinkWithinBase:
+		if len(f.AllowedBaseDirs) == 0 {
+			return ValidationError(fmt.Sprintf("symlink policy %q requires allowed base directories", SymlinkWithinBase), nil)
+		}
+	default:
+		return ValidationError(fmt.Sprintf("unsupported symlink policy %q, expected one of: %s, %s, %s",
+			f.SymlinkPolicy, SymlinkFollow, SymlinkWithinBase, SymlinkReject), nil)
+	}
+	return nil
+}
+
+// symlinkPolicy returns the policy applied to symbolic links: SymlinkFollow implies
+// SymlinkWithinBase when AllowedBaseDirs is set
+func (f *Flattener) symlinkPolicy() SymlinkPolicy {
+	if f.SymlinkPolicy == SymlinkFollow && len(f.AllowedBaseDirs) > 0 {
+		return SymlinkWithinBase
+	}
+	return f.SymlinkPolicy
+}
+
+// checkPath applies the path rules to a file path and returns the path to open: the
+// absolute path, or the resolved path when symbolic links are checked. The checks run
+// before the file is opened, so a link swapped in between by someone with write access
+// to the directories is not detected; AllowedBaseDirs should not be writable by
+// untrusted users.
+func (f *Flattener) checkPath(path string) (string, error) {
+	if path == "" {
+		return "", ValidationError("file path cannot be empty", nil)
+	}
+
+	cleanPath := filepath.Clean(path)
+	if strings.Contains(cleanPath, "..") {
+		return "", PathSecurityError("file path contains invalid directory traversal patterns").violates(PathRuleTraversal)
+	}
+
+	absPath, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return "", FileAccessError(fmt.Sprintf("invalid file path: %s", err), err)
+	}
+	if err := f.validatePathPolicy(); err != nil {
+		return "", err
+	}
+
+	base := ""
+	if len(f.AllowedBaseDirs) > 0 {
+		if base = f.baseDirOf(absPath, false); base == "" {
+			return "", PathSecurityError(fmt.Sprintf("file path %s is outside the allowed base directories %s",
+				path, strings.Join(f.AllowedBaseDirs, ", "))).violates(PathRuleBaseDir)
+		}
+	}
+	policy := f.symlinkPolicy()
+	if policy == SymlinkFollow {
+		return absPath, nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", FileAccessError(fmt.Sprintf("failed to access file: %s", err), err)
+		}
+		return "", FileAccessError(fmt.Sprintf("failed to resolve symbolic links: %s", err), err)
+	}
+
+	switch policy {
+	case SymlinkWithinBase:
+		if f.baseDirOf(resolved, true) == "" {
+			return "", PathSecurityError(fmt.Sprintf("file path %s resolves to %s through a symbolic link, outside the allowed base directories %s",
+				path, resolved, strings.Join(f.AllowedBaseDirs, ", "))).violates(PathRuleSymlink)
+		}
+	case SymlinkReject:
+		// links in the base directory itself, such as /tmp on macOS, are not the
+		// concern of the policy
+		expected := absPath
+		if base != "" {
+			rel, _ := filepath.Rel(base, absPath)
+			expected = filepath.Join(resolveDir(base), rel)
+		}
+		if resolved != expected {
+			return "", PathSecurityError(fmt.Sprintf("file path %s goes through a symbolic link to %s and symbolic links are rejected",
+				path, resolved)).violates(PathRuleSymlink)
+		}
+	}
+	return resolved, nil
+}
+
+// baseDirOf returns the absolute allowed base directory containing path, or "" if there
+// is none. resolved compares against the base directories with their symbolic links
+// resolved.
+func (f *Flattener) baseDirOf(path string, resolved bool) string {
+	for _, dir := range f.AllowedBaseDirs {
+		base, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if resolved {
+			base = resolveDir(base)
+		}
+		if rel, err := filepath.Rel(base, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return base
+		}
+	}
+	return ""
+}
+
+// resolveDir resolves the symbolic links of a directory, or returns it unchanged if it
+// cannot be resolved
+func resolveDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return dir
+}
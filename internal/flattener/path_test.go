@@ -0,0 +1,101 @@
+package flattener
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPathRules(t *testing.T) {
+	base := writeTree(t, map[string]string{
+		"config/app.yaml": "name: app\n",
+	})
+	outside := writeTree(t, map[string]string{
+		"secret.yaml": "password: hunter2\n",
+	})
+	inside := filepath.Join(base, "config", "app.yaml")
+	escape := filepath.Join(base, "config", "escape.yaml")
+	link := filepath.Join(base, "config", "link.yaml")
+	if err := os.Symlink(filepath.Join(outside, "secret.yaml"), escape); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+	if err := os.Symlink(inside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		baseDirs []string
+		policy   SymlinkPolicy
+		path     string
+		rule     string
+	}{
+		{name: "no base dirs", path: filepath.Join(outside, "secret.yaml")},
+		{name: "inside base dir", baseDirs: []string{base}, path: inside},
+		{name: "second base dir", baseDirs: []string{outside, base}, path: inside},
+		{name: "outside base dir", baseDirs: []string{base}, path: filepath.Join(outside, "secret.yaml"), rule: PathRuleBaseDir},
+		{name: "base dir prefix is not a parent", baseDirs: []string{base + "x"}, path: inside, rule: PathRuleBaseDir},
+		{name: "traversal", baseDirs: []string{base}, path: "config/../../etc/passwd", rule: PathRuleTraversal},
+		{name: "follow escaping link", baseDirs: []string{base}, path: escape, rule: PathRuleSymlink},
+		{name: "follow escaping link without base dirs", path: escape},
+		{name: "follow internal link", baseDirs: []string{base}, path: link},
+		{name: "within base escaping link", baseDirs: []string{base}, policy: SymlinkWithinBase, path: escape, rule: PathRuleSymlink},
+		{name: "within base internal link", baseDirs: []string{base}, policy: SymlinkWithinBase, path: link},
+		{name: "reject internal link", baseDirs: []string{base}, policy: SymlinkReject, path: link, rule: PathRuleSymlink},
+		{name: "reject without base dirs", policy: SymlinkReject, path: escape, rule: PathRuleSymlink},
+		{name: "reject plain file", baseDirs: []string{base}, policy: SymlinkReject, path: inside},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.AllowedBaseDirs = tt.baseDirs
+			if tt.policy != "" {
+				f.SymlinkPolicy = tt.policy
+			}
+
+			_, err := f.ReadFile(tt.path)
+			if tt.rule == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			assertErrorType(t, err, ErrTypePathSecurity)
+			var fe *Error
+			if errors.As(err, &fe) && fe.Rule != tt.rule {
+				t.Errorf("expected rule %q, got %q: %v", tt.rule, fe.Rule, err)
+			}
+		})
+	}
+}
+
+func TestPathPolicyValidation(t *testing.T) {
+	f := New()
+	f.SymlinkPolicy = "sometimes"
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+
+	f = New()
+	f.SymlinkPolicy = SymlinkWithinBase
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+
+	f = New()
+	f.AllowedBaseDirs = []string{""}
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+}
+
+func TestFlattenDirectoryAllowedBaseDirs(t *testing.T) {
+	root := writeTree(t, map[string]string{"app.yaml": "name: app\n"})
+
+	f := New()
+	f.AllowedBaseDirs = []string{filepath.Dir(root)}
+	if _, err := f.FlattenDirectory(root, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.AllowedBaseDirs = []string{filepath.Join(root, "sub")}
+	_, err := f.FlattenDirectory(root, nil, nil)
+	assertErrorType(t, err, ErrTypePathSecurity)
+}
@@ -0,0 +1,226 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLProfile selects the schema that decides the type of plain (untagged, unquoted)
+// YAML scalars, such as whether on and yes are booleans
+type YAMLProfile string
+
+const (
+	// YAMLProfileDefault resolves scalars like gopkg.in/yaml.v3: the YAML 1.2 core
+	// schema plus YAML 1.1 integers (012, 0b101, 1_000) and timestamps
+	YAMLProfileDefault YAMLProfile = "default"
+	// YAMLProfileYAML12 resolves scalars with the strict YAML 1.2 core schema: 012 is
+	// 12, and 0b101, 1_000, on, yes and 2026-01-02 are strings
+	YAMLProfileYAML12 YAMLProfile = "yaml12"
+	// YAMLProfileYAML11 resolves scalars with the YAML 1.1 types, as the YAML v2
+	// libraries behind Helm and Kubernetes do: y, yes, on, n, no and off are booleans,
+	// 012 is octal, 190:20:30 is sexagesimal and 1e5 without a dot is a string
+	YAMLProfileYAML11 YAMLProfile = "yaml11"
+	// YAMLProfileStrings reads every plain scalar as a string, like the YAML failsafe
+	// schema. Explicit tags such as !!int 5 are still honoured.
+	YAMLProfileStrings YAMLProfile = "strings"
+)
+
+// scalarSchema holds the patterns of one YAMLProfile, tried in order null, bool, int,
+// float
+type scalarSchema struct {
+	null  *regexp.Regexp
+	bool  *regexp.Regexp
+	int   *regexp.Regexp
+	float *regexp.Regexp
+	// truthy holds the lower-case booleans that are true
+	truthy     map[string]bool
+	parseInt   func(string) (*big.Int, bool)
+	parseFloat func(string) (float64, bool)
+}
+
+var (
+	yaml12Schema = &scalarSchema{
+		null:       regexp.MustCompile(`^(~|null|Null|NULL|)$`),
+		bool:       regexp.MustCompile(`^(true|True|TRUE|false|False|FALSE)$`),
+		int:        regexp.MustCompile(`^([-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+)$`),
+		float:      regexp.MustCompile(`^([-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`),
+		truthy:     map[string]bool{"true": true},
+		parseInt:   parseYAML12Int,
+		parseFloat: parseYAMLFloat,
+	}
+	yaml11Schema = &scalarSchema{
+		null:       regexp.MustCompile(`^(~|null|Null|NULL|)$`),
+		bool:       regexp.MustCompile(`^(y|Y|yes|Yes|YES|n|N|no|No|NO|true|True|TRUE|false|False|FALSE|on|On|ON|off|Off|OFF)$`),
+		int:        regexp.MustCompile(`^([-+]?0b[0-1_]+|[-+]?0[0-7_]+|[-+]?(0|[1-9][0-9_]*)|[-+]?0x[0-9a-fA-F_]+|[-+]?[1-9][0-9_]*(:[0-5]?[0-9])+)$`),
+		float:      regexp.MustCompile(`^([-+]?([0-9][0-9_]*\.[0-9_]*|\.[0-9][0-9_]*)([eE][-+][0-9]+)?|[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+\.[0-9_]*|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`),
+		truthy:     map[string]bool{"y": true, "yes": true, "on": true, "true": true},
+		parseInt:   parseYAML11Int,
+		parseFloat: parseYAML11Float,
+	}
+)
+
+// schema returns the scalar schema of the profile, nil when yaml.v3 resolves scalars
+func (f *Flattener) schema() *scalarSchema {
+	switch f.YAMLProfile {
+	case YAMLProfileYAML12:
+		return yaml12Schema
+	case YAMLProfileYAML11:
+		return yaml11Schema
+	default:
+		return nil
+	}
+}
+
+// isPlain reports whether a scalar node is neither quoted, a block scalar nor
+// explicitly tagged, so that its type is resolved from its text
+func isPlain(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0
+}
+
+// scalarTag returns the short tag of a scalar node under YAMLProfile
+func (w *walker) scalarTag(node *yaml.Node) string {
+	tag := node.ShortTag()
+	if w.YAMLProfile == YAMLProfileDefault || !isPlain(node) || tag == mergeTag {
+		return tag
+	}
+	if w.YAMLProfile == YAMLProfileStrings {
+		return strTag
+	}
+
+	schema := w.schema()
+	switch {
+	case schema.null.MatchString(node.Value):
+		return nullTag
+	case schema.bool.MatchString(node.Value):
+		return boolTag
+	case schema.int.MatchString(node.Value):
+		return intTag
+	case schema.float.MatchString(node.Value):
+		return floatTag
+	case w.YAMLProfile == YAMLProfileYAML11 && tag == timestampTag:
+		return tag
+	default:
+		return strTag
+	}
+}
+
+// profileScalar returns the canonical form and type of a plain scalar whose type the
+// YAMLProfile decides. It reports false when yaml.v3 decodes the scalar. Numbers the
+// patterns accept but that do not parse, such as 0b_, stay strings.
+func (w *walker) profileScalar(node *yaml.Node) (string, ValueType, bool) {
+	if w.YAMLProfile == YAMLProfileDefault || !isPlain(node) {
+		return "", "", false
+	}
+
+	switch w.scalarTag(node) {
+	case nullTag:
+		return "", ValueTypeNull, true
+	case boolTag:
+		return strconv.FormatBool(w.schema().truthy[strings.ToLower(node.Value)]), ValueTypeBool, true
+	case intTag:
+		if n, ok := w.schema().parseInt(node.Value); ok {
+			return n.String(), ValueTypeNumber, true
+		}
+	case floatTag:
+		if v, ok := w.schema().parseFloat(node.Value); ok {
+			return formatFloat(v), ValueTypeNumber, true
+		}
+	case timestampTag:
+		return "", "", false
+	}
+	return node.Value, ValueTypeString, true
+}
+
+// parseYAML12Int parses a YAML 1.2 core integer
+func parseYAML12Int(s string) (*big.Int, bool) {
+	switch {
+	case strings.HasPrefix(s, "0o"):
+		return new(big.Int).SetString(s[2:], 8)
+	case strings.HasPrefix(s, "0x"):
+		return new(big.Int).SetString(s[2:], 16)
+	default:
+		return new(big.Int).SetString(s, 10)
+	}
+}
+
+// parseYAML11Int parses a YAML 1.1 integer: binary, octal with a leading 0, decimal,
+// hexadecimal or sexagesimal, with _ as digit separator
+func parseYAML11Int(s string) (*big.Int, bool) {
+	s = strings.ReplaceAll(s, "_", "")
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	var n *big.Int
+	ok := true
+	switch {
+	case strings.Contains(s, ":"):
+		n = new(big.Int)
+		for _, part := range strings.Split(s, ":") {
+			digit, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			n.Mul(n, big.NewInt(60)).Add(n, big.NewInt(digit))
+		}
+	case strings.HasPrefix(s, "0b"):
+		n, ok = new(big.Int).SetString(s[2:], 2)
+	case strings.HasPrefix(s, "0x"):
+		n, ok = new(big.Int).SetString(s[2:], 16)
+	case len(s) > 1 && s[0] == '0':
+		n, ok = new(big.Int).SetString(s[1:], 8)
+	default:
+		n, ok = new(big.Int).SetString(s, 10)
+	}
+	if !ok {
+		return nil, false
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// parseYAMLFloat parses a YAML float, including .inf and .nan
+func parseYAMLFloat(s string) (float64, bool) {
+	switch strings.ToLower(strings.TrimLeft(s, "+-")) {
+	case ".inf":
+		if strings.HasPrefix(s, "-") {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case ".nan":
+		return math.NaN(), true
+	}
+	// out of range floats parse as infinities
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil || errors.Is(err, strconv.ErrRange)
+}
+
+// parseYAML11Float parses a YAML 1.1 float, with _ as digit separator and sexagesimal
+// notation such as 190:20:30.15
+func parseYAML11Float(s string) (float64, bool) {
+	s = strings.ReplaceAll(s, "_", "")
+	if !strings.Contains(s, ":") {
+		return parseYAMLFloat(s)
+	}
+	negative := strings.HasPrefix(s, "-")
+	var v float64
+	for _, part := range strings.Split(strings.TrimLeft(s, "+-"), ":") {
+		digit, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		v = v*60 + digit
+	}
+	if negative {
+		v = -v
+	}
+	return v, true
+}
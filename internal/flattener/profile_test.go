@@ -0,0 +1,107 @@
+package flattener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenYAMLProfile(t *testing.T) {
+	yamlStr := `enabled: on
+answer: yes
+short: n
+octal: 012
+hex: 0x1F
+binary: 0b101
+grouped: 1_000
+sexagesimal: 1:30
+exponent: 1e5
+ratio: .5
+date: 2026-01-02
+nothing: ~
+quoted: "on"
+tagged: !!int "7"
+`
+
+	tests := []struct {
+		profile  YAMLProfile
+		expected map[string]string
+	}{
+		{
+			profile: YAMLProfileDefault,
+			expected: map[string]string{
+				"enabled": "on", "answer": "yes", "short": "n", "octal": "10", "hex": "31",
+				"binary": "5", "grouped": "1000", "sexagesimal": "1:30", "exponent": "100000",
+				"ratio": "0.5", "date": "2026-01-02T00:00:00Z", "nothing": "", "quoted": "on", "tagged": "7",
+			},
+		},
+		{
+			profile: YAMLProfileYAML12,
+			expected: map[string]string{
+				"enabled": "on", "answer": "yes", "short": "n", "octal": "12", "hex": "31",
+				"binary": "0b101", "grouped": "1_000", "sexagesimal": "1:30", "exponent": "100000",
+				"ratio": "0.5", "date": "2026-01-02", "nothing": "", "quoted": "on", "tagged": "7",
+			},
+		},
+		{
+			profile: YAMLProfileYAML11,
+			expected: map[string]string{
+				"enabled": "true", "answer": "true", "short": "false", "octal": "10", "hex": "31",
+				"binary": "5", "grouped": "1000", "sexagesimal": "90", "exponent": "1e5",
+				"ratio": "0.5", "date": "2026-01-02T00:00:00Z", "nothing": "", "quoted": "on", "tagged": "7",
+			},
+		},
+		{
+			profile: YAMLProfileStrings,
+			expected: map[string]string{
+				"enabled": "on", "answer": "yes", "short": "n", "octal": "012", "hex": "0x1F",
+				"binary": "0b101", "grouped": "1_000", "sexagesimal": "1:30", "exponent": "1e5",
+				"ratio": ".5", "date": "2026-01-02", "nothing": "~", "quoted": "on", "tagged": "7",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.profile), func(t *testing.T) {
+			f := New()
+			f.YAMLProfile = tt.profile
+
+			flat, err := f.FlattenYAMLString(yamlStr)
+			if err != nil {
+				t.Fatalf("FlattenYAMLString() error = %v", err)
+			}
+			if !reflect.DeepEqual(flat, tt.expected) {
+				t.Errorf("FlattenYAMLString() = %v, want %v", flat, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFlattenYAMLProfileTypesAndKeys(t *testing.T) {
+	f := New()
+	f.YAMLProfile = YAMLProfileYAML11
+
+	result, err := f.FlattenString("on: 1\nlevel: -0b11\nangle: -1:30.5\n")
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+	expected := map[string]string{"true": "1", "level": "-3", "angle": "-90.5"}
+	if !reflect.DeepEqual(result.Values, expected) {
+		t.Errorf("Values = %v, want %v", result.Values, expected)
+	}
+	if result.Types["level"] != ValueTypeNumber || result.Types["angle"] != ValueTypeNumber {
+		t.Errorf("Types = %v", result.Types)
+	}
+
+	f.YAMLProfile = YAMLProfileStrings
+	f.ScalarFormat = ScalarFormatPreserve
+	result, err = f.FlattenString("count: 3\n")
+	if err != nil {
+		t.Fatalf("FlattenString() error = %v", err)
+	}
+	if result.Types["count"] != ValueTypeString {
+		t.Errorf("Types = %v, want string", result.Types)
+	}
+
+	f.YAMLProfile = "yaml13"
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+}
@@ -0,0 +1,135 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// decodeProperties decodes Java .properties content following the format of
+// java.util.Properties: # and ! start comments, keys end at the first unescaped =, :
+// or whitespace, lines ending in a backslash continue on the next line, and \t, \n,
+// \r, \f and \uXXXX escapes are supported. Keys are used as they are, so a.b=c gives
+// the key "a.b" whatever the separator. When a key repeats, the last value wins at the
+// position of the first.
+func decodeProperties(content string) (interface{}, error) {
+	result := newOrderedMap()
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// join continuation lines, dropping the leading whitespace of each
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if endsWithContinuation(line) {
+			line = line[:len(line)-1]
+		}
+
+		rawKey, rawValue := splitProperty(line)
+		key, err := unescapeProperty(rawKey)
+		if err != nil {
+			return nil, PropertiesParsingError("invalid key", err).atLine(lineNum, 0)
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return nil, PropertiesParsingError(fmt.Sprintf("invalid value for key %q", key), err).atLine(lineNum, 0)
+		}
+		result.set(key, value)
+	}
+
+	return result, nil
+}
+
+// endsWithContinuation reports whether line ends in an odd number of backslashes
+func endsWithContinuation(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line at the first unescaped =, : or whitespace
+// into the raw key and value
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return line[:end], rest
+}
+
+// unescapeProperty resolves backslash escapes in a key or value
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("incomplete \\u escape")
+			}
+			r, err := parseUnicodeEscape(s[i+1 : i+5])
+			if err != nil {
+				return "", err
+			}
+			i += 4
+			// join UTF-16 surrogate pairs written as two escapes
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], "\\u") && i+7 <= len(s) {
+				if low, err := parseUnicodeEscape(s[i+3 : i+7]); err == nil {
+					if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+						r = pair
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func parseUnicodeEscape(hex string) (rune, error) {
+	code, err := strconv.ParseUint(hex, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("malformed \\u escape \\u%s", hex)
+	}
+	return rune(code), nil
+}
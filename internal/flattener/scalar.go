@@ -0,0 +1,116 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"encoding/base64"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScalarFormat controls how YAML scalars are written as flattened values
+type ScalarFormat string
+
+const (
+	// ScalarFormatCanonical writes every scalar in one documented form per type, see
+	// nodeScalar
+	ScalarFormatCanonical ScalarFormat = "canonical"
+	// ScalarFormatPreserve writes scalars exactly as they appear in the YAML source, so
+	// 0x1F, 1e21, 2026-01-02 and ~ stay as written. Value types still follow the tag.
+	ScalarFormatPreserve ScalarFormat = "preserve"
+)
+
+// YAML tags rendered specially in canonical form
+const (
+	intTag       = "!!int"
+	floatTag     = "!!float"
+	boolTag      = "!!bool"
+	binaryTag    = "!!binary"
+	timestampTag = "!!timestamp"
+)
+
+// bigIntegerPattern matches decimal integers too large for uint64, which YAML resolves
+// as floats
+var bigIntegerPattern = regexp.MustCompile(`^[-+]?[0-9]+$`)
+
+// nodeScalar returns the flattened form and type of a scalar node according to
+// ScalarFormat, with its type decided by YAMLProfile. Canonical form is:
+//   - null: the empty string
+//   - bool: true or false
+//   - int: base 10 digits with a leading - for negative numbers, at any size
+//   - float: the shortest decimal that reads back as the same float64, in exponent
+//     notation (1e+21, 1e-7) below 1e-6 and from 1e21 on, and .inf, -.inf and .nan
+//   - timestamp: RFC 3339 with fractional seconds only when present, keeping the
+//     offset as written; dates without a time are midnight UTC
+//   - binary: the base64 text without line breaks
+//   - str and unknown tags: the text as written
+func (w *walker) nodeScalar(node *yaml.Node) (string, ValueType, error) {
+	tag := w.scalarTag(node)
+	if w.ScalarFormat == ScalarFormatPreserve {
+		return node.Value, tagType(tag), nil
+	}
+	if s, valueType, ok := w.profileScalar(node); ok {
+		return s, valueType, nil
+	}
+
+	switch tag {
+	case binaryTag:
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+		if err != nil {
+			return "", "", ParsingError("invalid !!binary value", err).atLine(node.Line, node.Column)
+		}
+		return base64.StdEncoding.EncodeToString(data), ValueTypeString, nil
+	case floatTag:
+		if bigIntegerPattern.MatchString(node.Value) {
+			if n, ok := new(big.Int).SetString(node.Value, 10); ok {
+				return n.String(), ValueTypeNumber, nil
+			}
+		}
+	}
+
+	value, err := scalarValue(node)
+	if err != nil {
+		return "", "", err
+	}
+	s, valueType := scalarString(value)
+	return s, valueType, nil
+}
+
+// tagType returns the value type of a scalar with the given short tag
+func tagType(tag string) ValueType {
+	switch tag {
+	case intTag, floatTag:
+		return ValueTypeNumber
+	case boolTag:
+		return ValueTypeBool
+	case nullTag:
+		return ValueTypeNull
+	default:
+		return ValueTypeString
+	}
+}
+
+// formatFloat writes a float in canonical form, see nodeScalar
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return ".inf"
+	case math.IsInf(v, -1):
+		return "-.inf"
+	case math.IsNaN(v):
+		return ".nan"
+	}
+	if abs := math.Abs(v); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		s := strconv.FormatFloat(v, 'e', -1, 64)
+		// drop the leading zero of two-digit exponents, 1e-07 becomes 1e-7
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+		return s
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,124 @@
+package flattener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenScalarFormat(t *testing.T) {
+	yamlStr := `int: 0x1F
+big: 123456789012345678901234567890
+uint: 18446744073709551615
+float: 1.50
+huge: 1e21
+tiny: 0.0000001
+inf: -.inf
+nan: .nan
+date: 2026-01-02
+time: 2026-01-02T10:00:00.5+02:00
+binary: !!binary |
+  aGVs
+  bG8=
+bool: True
+null: ~
+custom: !thing value
+`
+
+	tests := []struct {
+		format   ScalarFormat
+		expected map[string]string
+	}{
+		{
+			format: ScalarFormatCanonical,
+			expected: map[string]string{
+				"int":    "31",
+				"big":    "123456789012345678901234567890",
+				"uint":   "18446744073709551615",
+				"float":  "1.5",
+				"huge":   "1e+21",
+				"tiny":   "1e-7",
+				"inf":    "-.inf",
+				"nan":    ".nan",
+				"date":   "2026-01-02T00:00:00Z",
+				"time":   "2026-01-02T10:00:00.5+02:00",
+				"binary": "aGVsbG8=",
+				"bool":   "true",
+				"null":   "",
+				"custom": "value",
+			},
+		},
+		{
+			format: ScalarFormatPreserve,
+			expected: map[string]string{
+				"int":    "0x1F",
+				"big":    "123456789012345678901234567890",
+				"uint":   "18446744073709551615",
+				"float":  "1.50",
+				"huge":   "1e21",
+				"tiny":   "0.0000001",
+				"inf":    "-.inf",
+				"nan":    ".nan",
+				"date":   "2026-01-02",
+				"time":   "2026-01-02T10:00:00.5+02:00",
+				"binary": "aGVs\nbG8=\n",
+				"bool":   "True",
+				"null":   "~",
+				"custom": "value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			f := New()
+			f.ScalarFormat = tt.format
+
+			result, err := f.FlattenString(yamlStr)
+			if err != nil {
+				t.Fatalf("FlattenString() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("Values = %v, want %v", result.Values, tt.expected)
+			}
+			if result.Types["big"] != ValueTypeNumber || result.Types["bool"] != ValueTypeBool || result.Types["null"] != ValueTypeNull {
+				t.Errorf("Types = %v", result.Types)
+			}
+		})
+	}
+}
+
+func TestFlattenScalarFormatKeys(t *testing.T) {
+	f := New()
+	f.ScalarFormat = ScalarFormatPreserve
+
+	flat, err := f.FlattenYAMLString("ports:\n  0x50: http\n  ~: none\n")
+	if err != nil {
+		t.Fatalf("FlattenYAMLString() error = %v", err)
+	}
+	if want := map[string]string{"ports.0x50": "http", "ports.~": "none"}; !reflect.DeepEqual(flat, want) {
+		t.Errorf("FlattenYAMLString() = %v, want %v", flat, want)
+	}
+
+	_, err = New().FlattenString("data: !!binary '%%%'\n")
+	assertErrorType(t, err, ErrTypeParsing)
+
+	f.ScalarFormat = "raw"
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := map[float64]string{
+		0:        "0",
+		1.5:      "1.5",
+		-2.25e20: "-225000000000000000000",
+		1e21:     "1e+21",
+		1.5e-7:   "1.5e-7",
+		1e-6:     "0.000001",
+		1e100:    "1e+100",
+	}
+	for v, expected := range tests {
+		if got := formatFloat(v); got != expected {
+			t.Errorf("formatFloat(%v) = %q, want %q", v, got, expected)
+		}
+	}
+}
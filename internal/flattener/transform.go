@@ -0,0 +1,188 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// KeyTransform names a step of the key transform pipeline
+type KeyTransform string
+
+const (
+	// KeyTransformUpper converts the key to upper case
+	KeyTransformUpper KeyTransform = "upper"
+	// KeyTransformLower converts the key to lower case
+	KeyTransformLower KeyTransform = "lower"
+	// KeyTransformSnake splits the key into words and joins them in lower case with _
+	KeyTransformSnake KeyTransform = "snake"
+	// KeyTransformKebab splits the key into words and joins them in lower case with -
+	KeyTransformKebab KeyTransform = "kebab"
+	// KeyTransformCamel splits the key into words and joins them in camelCase
+	KeyTransformCamel KeyTransform = "camel"
+)
+
+// keyTransformer applies the key transform pipeline of a Flattener
+type keyTransformer struct {
+	steps       []KeyTransform
+	illegal     *regexp.Regexp
+	replacement string
+	prefix      string
+}
+
+// compileKeyTransformer validates the key transform settings. It returns nil when keys
+// are left unchanged.
+func (f *Flattener) compileKeyTransformer() (*keyTransformer, error) {
+	for _, step := range f.KeyTransforms {
+		switch step {
+		case KeyTransformUpper, KeyTransformLower, KeyTransformSnake, KeyTransformKebab, KeyTransformCamel:
+		default:
+			return nil, ValidationError(fmt.Sprintf("unsupported key transform %q, expected one of: %s, %s, %s, %s, %s",
+				step, KeyTransformUpper, KeyTransformLower, KeyTransformSnake, KeyTransformKebab, KeyTransformCamel), nil)
+		}
+	}
+
+	var illegal *regexp.Regexp
+	if f.KeyIllegalChars != "" {
+		re, err := regexp.Compile(f.KeyIllegalChars)
+		if err != nil {
+			return nil, ValidationError(fmt.Sprintf("invalid key illegal characters pattern %q", f.KeyIllegalChars), err)
+		}
+		illegal = re
+	}
+
+	if len(f.KeyTransforms) == 0 && illegal == nil && f.KeyPrefix == "" {
+		return nil, nil
+	}
+
+	return &keyTransformer{
+		steps:       f.KeyTransforms,
+		illegal:     illegal,
+		replacement: f.KeyReplacement,
+		prefix:      f.KeyPrefix,
+	}, nil
+}
+
+// transform runs a single key through the pipeline
+func (kt *keyTransformer) transform(key string) string {
+	for _, step := range kt.steps {
+		switch step {
+		case KeyTransformUpper:
+			key = strings.ToUpper(key)
+		case KeyTransformLower:
+			key = strings.ToLower(key)
+		case KeyTransformSnake:
+			key = strings.ToLower(strings.Join(splitWords(key), "_"))
+		case KeyTransformKebab:
+			key = strings.ToLower(strings.Join(splitWords(key), "-"))
+		case KeyTransformCamel:
+			key = camelCase(splitWords(key))
+		}
+	}
+	if kt.illegal != nil {
+		key = kt.illegal.ReplaceAllLiteralString(key, kt.replacement)
+	}
+	return kt.prefix + key
+}
+
+// transformKeys runs every key of the result through the key transforms, keeping the
+// document order. Keys that transform to the same key are resolved by KeyCollisions.
+func (w *walker) transformKeys() (*Result, error) {
+	r := w.result
+	w.result = newResult()
+	w.result.Warnings = r.Warnings
+	originals := make(map[string]string, len(r.Keys))
+	for _, k := range r.Keys {
+		newKey := w.keys.transform(k)
+		if newKey == "" {
+			return nil, locatedAt(ValidationError(fmt.Sprintf("key %q transforms to an empty key", k), nil), k, r)
+		}
+		describe := func(key string) string {
+			return fmt.Sprintf("keys %q and %q both transform to %q", originals[key], k, key)
+		}
+		stored, err := w.storeWith(newKey, r.Values[k], r.Types[k], describe)
+		if err != nil {
+			var fe *Error
+			if errors.As(err, &fe) {
+				locatedAt(fe, k, r)
+			}
+			return nil, err
+		}
+		if !stored {
+			continue
+		}
+		originals[newKey] = k
+		if location, ok := r.Locations[k]; ok {
+			w.result.Locations[newKey] = location
+		}
+		if anchor, ok := r.Anchors[k]; ok {
+			w.result.Anchors[newKey] = anchor
+		}
+		if source, ok := r.Sources[k]; ok {
+			w.result.Sources[newKey] = source
+		}
+	}
+	return w.result, nil
+}
+
+// locatedAt sets the path of err to key and its position to the location of key in r
+func locatedAt(err *Error, key string, r *Result) *Error {
+	err.at(key)
+	if location, ok := r.Locations[key]; ok {
+		err.File = location.File
+		err.atLine(location.Line, location.Column)
+	}
+	return err
+}
+
+// splitWords splits a key into words at every character that is not a letter or digit
+// and at camelCase boundaries, so "database.primaryHost[0]" gives database, primary,
+// Host and 0, and "HTTPServer" gives HTTP and Server.
+func splitWords(key string) []string {
+	var words []string
+	runes := []rune(key)
+	start := -1
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// camelCase joins words with the first in lower case and the others capitalized
+func camelCase(words []string) string {
+	var b strings.Builder
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			runes := []rune(w)
+			runes[0] = unicode.ToUpper(runes[0])
+			w = string(runes)
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package flattener
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenKeyTransforms(t *testing.T) {
+	yamlStr := `
+database:
+  primaryHost: db1
+  replicas:
+    - host: r1
+HTTPServer:
+  max-connections: 10
+`
+
+	tests := []struct {
+		name        string
+		transforms  []KeyTransform
+		illegal     string
+		replacement string
+		prefix      string
+		expected    map[string]string
+	}{
+		{
+			name:       "Environment variable style",
+			transforms: []KeyTransform{KeyTransformSnake, KeyTransformUpper},
+			prefix:     "APP_",
+			expected: map[string]string{
+				"APP_DATABASE_PRIMARY_HOST":       "db1",
+				"APP_DATABASE_REPLICAS_0_HOST":    "r1",
+				"APP_HTTP_SERVER_MAX_CONNECTIONS": "10",
+			},
+		},
+		{
+			name:       "Snake",
+			transforms: []KeyTransform{KeyTransformSnake},
+			expected: map[string]string{
+				"database_primary_host":       "db1",
+				"database_replicas_0_host":    "r1",
+				"http_server_max_connections": "10",
+			},
+		},
+		{
+			name:       "Kebab",
+			transforms: []KeyTransform{KeyTransformKebab},
+			expected: map[string]string{
+				"database-primary-host":       "db1",
+				"database-replicas-0-host":    "r1",
+				"http-server-max-connections": "10",
+			},
+		},
+		{
+			name:       "Camel",
+			transforms: []KeyTransform{KeyTransformCamel},
+			expected: map[string]string{
+				"databasePrimaryHost":      "db1",
+				"databaseReplicas0Host":    "r1",
+				"httpServerMaxConnections": "10",
+			},
+		},
+		{
+			name:        "Upper with illegal character replacement",
+			transforms:  []KeyTransform{KeyTransformUpper},
+			illegal:     "[^A-Z0-9_]+",
+			replacement: "_",
+			expected: map[string]string{
+				"DATABASE_PRIMARYHOST":       "db1",
+				"DATABASE_REPLICAS_0_HOST":   "r1",
+				"HTTPSERVER_MAX_CONNECTIONS": "10",
+			},
+		},
+		{
+			name:       "Lower keeps notation",
+			transforms: []KeyTransform{KeyTransformLower},
+			expected: map[string]string{
+				"database.primaryhost":       "db1",
+				"database.replicas[0].host":  "r1",
+				"httpserver.max-connections": "10",
+			},
+		},
+		{
+			name:   "Prefix only",
+			prefix: "cfg.",
+			expected: map[string]string{
+				"cfg.database.primaryHost":       "db1",
+				"cfg.database.replicas[0].host":  "r1",
+				"cfg.HTTPServer.max-connections": "10",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New()
+			f.KeyTransforms = tt.transforms
+			f.KeyIllegalChars = tt.illegal
+			f.KeyReplacement = tt.replacement
+			f.KeyPrefix = tt.prefix
+
+			result, err := f.FlattenString(yamlStr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result.Values, tt.expected) {
+				t.Errorf("FlattenString() = %v, want %v", result.Values, tt.expected)
+			}
+			if len(result.Types) != len(result.Values) {
+				t.Errorf("types not transformed with values: %v", result.Types)
+			}
+		})
+	}
+}
+
+func TestFlattenKeyTransformCollision(t *testing.T) {
+	f := New()
+	f.KeyTransforms = []KeyTransform{KeyTransformSnake, KeyTransformUpper}
+
+	_, err := f.FlattenString("db:\n  host: a\ndb_host: b\n")
+	assertErrorType(t, err, ErrTypeValidation)
+	if err == nil || !strings.Contains(err.Error(), `"db.host" and "db_host" both transform to "DB_HOST"`) {
+		t.Errorf("expected collision error naming both keys, got %v", err)
+	}
+}
+
+func TestFlattenKeyTransformCollisionPolicies(t *testing.T) {
+	yamlStr := "db:\n  host: a\ndb_host: b\n"
+
+	tests := []struct {
+		policy   KeyCollisions
+		expected string
+		line     int
+		warnings int
+	}{
+		{policy: KeyCollisionsWarn, expected: "a", line: 2, warnings: 1},
+		{policy: KeyCollisionsFirstWins, expected: "a", line: 2},
+		{policy: KeyCollisionsLastWins, expected: "b", line: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			f := New()
+			f.KeyTransforms = []KeyTransform{KeyTransformSnake, KeyTransformUpper}
+			f.KeyCollisions = tt.policy
+
+			result, err := f.FlattenString(yamlStr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Keys) != 1 || result.Values["DB_HOST"] != tt.expected {
+				t.Errorf("expected DB_HOST = %q only, got %v", tt.expected, result.Values)
+			}
+			if location := result.Locations["DB_HOST"]; location.Line != tt.line {
+				t.Errorf("expected the location of the kept value, got %v", location)
+			}
+			if len(result.Warnings) != tt.warnings {
+				t.Errorf("expected %d warnings, got %v", tt.warnings, result.Warnings)
+			}
+			if tt.warnings > 0 && !strings.Contains(result.Warnings[0], `"db.host" and "db_host" both transform to "DB_HOST"`) {
+				t.Errorf("expected warning naming both keys, got %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestFlattenKeyTransformsAfterFilter(t *testing.T) {
+	f := New()
+	f.KeyTransforms = []KeyTransform{KeyTransformSnake, KeyTransformUpper}
+	f.Include = []string{"database.*"}
+
+	result, err := f.FlattenString("database:\n  host: db1\ncache:\n  host: redis\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Values, map[string]string{"DATABASE_HOST": "db1"}) {
+		t.Errorf("FlattenString() = %v", result.Values)
+	}
+}
+
+func TestKeyTransformValidation(t *testing.T) {
+	f := New()
+	f.KeyTransforms = []KeyTransform{"title"}
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+
+	f = New()
+	f.KeyIllegalChars = "[a-"
+	assertErrorType(t, f.Validate(), ErrTypeValidation)
+
+	f = New()
+	f.KeyIllegalChars = ".*"
+	_, err := f.FlattenString("a: 1")
+	assertErrorType(t, err, ErrTypeValidation)
+}
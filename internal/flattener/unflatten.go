@@ -0,0 +1,173 @@
+// Package flattener provides functionality to flatten YAML structures into key-value pairs.
+package flattener
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Unflatten rebuilds a nested structure from a flattened map. Keys are parsed by ParseKey
+// using the configured separator, array style and key escaping, so the result of
+// FlattenYAML with the same settings round-trips: flattening the unflattened value yields
+// the original map.
+//
+// Arrays are filled with nil up to the highest index of their keys. MaxResultSize bounds
+// the array elements allocated in total, so sparse indexes such as a[99999] cannot make
+// a small map allocate far more than it holds. With ArrayStyleDotted a segment of only
+// digits is an array index, so the object key 80 of ports.80 must be escaped by
+// KeyEscaping to be read back as an object key.
+func (f *Flattener) Unflatten(flat map[string]string) (interface{}, error) {
+	if flat == nil {
+		return nil, ValidationError("cannot unflatten nil map", nil)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(flat) > f.MaxResultSize {
+		return nil, SizeLimitError(f.MaxResultSize, "input map").limitedBy("MaxResultSize")
+	}
+
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	u := &unflattener{Flattener: f}
+	var root interface{}
+	for _, k := range keys {
+		segments, err := f.ParseKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) > f.MaxNestingDepth {
+			return nil, DepthLimitError(f.MaxNestingDepth).at(k)
+		}
+
+		root, err = u.insertValue(root, segments, flat[k], k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if root == nil {
+		root = map[string]interface{}{}
+	}
+
+	return root, nil
+}
+
+// UnflattenToYAML rebuilds a nested structure from a flattened map and encodes it as YAML
+func (f *Flattener) UnflattenToYAML(flat map[string]string) (string, error) {
+	data, err := f.Unflatten(flat)
+	if err != nil {
+		return "", err
+	}
+	return UnflattenedToYAML(data)
+}
+
+// UnflattenToJSON rebuilds a nested structure from a flattened map and encodes it as JSON
+func (f *Flattener) UnflattenToJSON(flat map[string]string) (string, error) {
+	data, err := f.Unflatten(flat)
+	if err != nil {
+		return "", err
+	}
+	return UnflattenedToJSON(data)
+}
+
+// UnflattenedToYAML encodes a structure returned by Unflatten as YAML
+func UnflattenedToYAML(data interface{}) (string, error) {
+	out, err := yaml.Marshal(data)
+	if err != nil {
+		return "", ValidationError("failed to encode YAML", err)
+	}
+
+	return string(out), nil
+}
+
+// UnflattenedToJSON encodes a structure returned by Unflatten as JSON
+func UnflattenedToJSON(data interface{}) (string, error) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return "", ValidationError("failed to encode JSON", err)
+	}
+
+	return string(out), nil
+}
+
+// unflattener holds the state of one Unflatten call
+type unflattener struct {
+	*Flattener
+	// elements counts the array elements allocated so far
+	elements int
+}
+
+// insertValue places value at the path described by segments below node and returns the
+// updated node. Arrays may be reallocated while growing, so callers must store the result.
+func (u *unflattener) insertValue(node interface{}, segments []KeySegment, value, key string) (interface{}, error) {
+	if len(segments) == 0 {
+		if node != nil {
+			return nil, ValidationError(fmt.Sprintf("key %q conflicts with another key using the same path", key), nil)
+		}
+		return u.leafValue(value), nil
+	}
+
+	seg := segments[0]
+
+	if seg.IsIndex {
+		if node == nil {
+			node = []interface{}{}
+		}
+		arr, ok := node.([]interface{})
+		if !ok {
+			return nil, ValidationError(fmt.Sprintf("key %q uses an array index where another key uses an object", key), nil)
+		}
+		if seg.Index >= len(arr) {
+			if seg.Index-len(arr) >= u.MaxResultSize-u.elements {
+				return nil, SizeLimitError(u.MaxResultSize, "array elements").limitedBy("MaxResultSize").at(key)
+			}
+			grow := seg.Index + 1 - len(arr)
+			u.elements += grow
+			arr = append(arr, make([]interface{}, grow)...)
+		}
+		child, err := u.insertValue(arr[seg.Index], segments[1:], value, key)
+		if err != nil {
+			return nil, err
+		}
+		arr[seg.Index] = child
+		return arr, nil
+	}
+
+	if node == nil {
+		node = map[string]interface{}{}
+	}
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, ValidationError(fmt.Sprintf("key %q uses an object key where another key uses a value or array", key), nil)
+	}
+	child, err := u.insertValue(obj[seg.Key], segments[1:], value, key)
+	if err != nil {
+		return nil, err
+	}
+	obj[seg.Key] = child
+	return obj, nil
+}
+
+// leafValue returns the value stored for a flattened value: an empty object or array for
+// the literals of EmptyCollectionsLiteral, the string itself otherwise
+func (f *Flattener) leafValue(value string) interface{} {
+	if f.EmptyCollections == EmptyCollectionsLiteral {
+		switch value {
+		case "{}":
+			return map[string]interface{}{}
+		case "[]":
+			return []interface{}{}
+		}
+	}
+	return value
+}
@@ -0,0 +1,267 @@
+package flattener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		flat     map[string]string
+		expected interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "Empty map",
+			flat:     map[string]string{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "Nested objects and arrays",
+			flat: map[string]string{
+				"key":                       "value",
+				"database.primary.host":     "db1",
+				"database.replicas[0].host": "r1",
+				"database.replicas[1].host": "r2",
+				"matrix[0][1]":              "b",
+			},
+			expected: map[string]interface{}{
+				"key": "value",
+				"database": map[string]interface{}{
+					"primary": map[string]interface{}{"host": "db1"},
+					"replicas": []interface{}{
+						map[string]interface{}{"host": "r1"},
+						map[string]interface{}{"host": "r2"},
+					},
+				},
+				"matrix": []interface{}{
+					[]interface{}{nil, "b"},
+				},
+			},
+		},
+		{
+			name:     "Root array",
+			flat:     map[string]string{"[0]": "a", "[1].name": "b"},
+			expected: []interface{}{"a", map[string]interface{}{"name": "b"}},
+		},
+		{
+			name:     "Literal brackets kept in key",
+			flat:     map[string]string{"app[beta]": "on", "a[0]x": "y"},
+			expected: map[string]interface{}{"app[beta]": "on", "a[0]x": "y"},
+		},
+		{
+			name:    "Value and object on same path",
+			flat:    map[string]string{"a": "x", "a.b": "y"},
+			wantErr: true,
+		},
+		{
+			name:    "Array and object on same path",
+			flat:    map[string]string{"a[0]": "x", "a.b": "y"},
+			wantErr: true,
+		},
+		{
+			name:    "Nil map",
+			flat:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().Unflatten(tt.flat)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unflatten() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				assertErrorType(t, err, ErrTypeValidation)
+				return
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Unflatten() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnflattenArrayElementLimit(t *testing.T) {
+	f := New()
+	f.MaxResultSize = 100
+
+	if _, err := f.Unflatten(map[string]string{"a[99]": "x"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		flat map[string]string
+	}{
+		{name: "Sparse index", flat: map[string]string{"a[100]": "x"}},
+		{name: "Sparse indexes across keys", flat: map[string]string{"a[60]": "x", "b[60]": "y"}},
+		{name: "Nested sparse indexes", flat: map[string]string{"a[9][9]": "x", "a[8][99]": "y"}},
+		{name: "Index beyond int range", flat: map[string]string{"a[9223372036854775807]": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.Unflatten(tt.flat)
+			assertErrorType(t, err, ErrTypeSizeLimit)
+		})
+	}
+}
+
+func TestUnflattenDottedNumericKeys(t *testing.T) {
+	f := New()
+	f.ArrayStyle = ArrayStyleDotted
+
+	result, err := f.Unflatten(map[string]string{"ports.2": "http"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]interface{}{"ports": []interface{}{nil, nil, "http"}}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("Unflatten() = %#v, want %#v", result, expected)
+	}
+
+	// escaped, the numeric key round-trips as an object key
+	f.KeyEscaping = KeyEscapingBackslash
+	flat, err := f.FlattenYAMLString("ports:\n  80: http\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err = f.Unflatten(flat)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]interface{}{"ports": map[string]interface{}{"80": "http"}}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("Unflatten(%v) = %#v, want %#v", flat, result, expected)
+	}
+}
+
+func TestUnflattenRoundTrip(t *testing.T) {
+	yamlStr := `
+application:
+  name: example
+  port: 8080
+  enabled: true
+  ratio: 0.5
+  empty: null
+database:
+  replicas:
+    - host: r1
+      tags: [a, b]
+    - host: r2
+"key.with.dots": dots
+"app[beta]": beta
+matrix:
+  - [1, 2]
+  - [3, 4]
+`
+
+	notations := []struct {
+		name          string
+		separator     string
+		arrayStyle    ArrayStyle
+		arrayTemplate string
+	}{
+		{name: "brackets", separator: ".", arrayStyle: ArrayStyleBrackets},
+		{name: "dotted", separator: ".", arrayStyle: ArrayStyleDotted},
+		{name: "slash dotted", separator: "/", arrayStyle: ArrayStyleDotted},
+		{name: "env template", separator: "__", arrayStyle: ArrayStyleTemplate, arrayTemplate: "__{index}"},
+		{name: "suffix template", separator: ".", arrayStyle: ArrayStyleTemplate, arrayTemplate: "<{index}>"},
+	}
+
+	for _, n := range notations {
+		t.Run(n.name, func(t *testing.T) {
+			f := New()
+			f.Separator = n.separator
+			f.ArrayStyle = n.arrayStyle
+			f.ArrayTemplate = n.arrayTemplate
+
+			flat, err := f.FlattenYAMLString(yamlStr)
+			if err != nil {
+				t.Fatalf("FlattenYAMLString() error: %v", err)
+			}
+
+			for _, encode := range []func(map[string]string) (string, error){f.UnflattenToYAML, f.UnflattenToJSON} {
+				encoded, err := encode(flat)
+				if err != nil {
+					t.Fatalf("unflatten error: %v", err)
+				}
+
+				again, err := f.FlattenYAMLString(encoded)
+				if err != nil {
+					t.Fatalf("re-flatten error: %v\n%s", err, encoded)
+				}
+
+				if !reflect.DeepEqual(again, flat) {
+					t.Errorf("round trip mismatch:\ngot  %v\nwant %v\nencoded:\n%s", again, flat, encoded)
+				}
+			}
+		})
+	}
+}
+
+func TestUnflattenEmptyCollections(t *testing.T) {
+	f := New()
+	f.EmptyCollections = EmptyCollectionsLiteral
+
+	flat, err := f.FlattenYAMLString("tags: {}\nitems: []\nname: app\n")
+	if err != nil {
+		t.Fatalf("FlattenYAMLString() error: %v", err)
+	}
+
+	data, err := f.Unflatten(flat)
+	if err != nil {
+		t.Fatalf("Unflatten() error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"tags":  map[string]interface{}{},
+		"items": []interface{}{},
+		"name":  "app",
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Unflatten() = %v, want %v", data, expected)
+	}
+
+	// other policies keep the literals as strings
+	data, err = New().Unflatten(map[string]string{"tags": "{}"})
+	if err != nil {
+		t.Fatalf("Unflatten() error: %v", err)
+	}
+	if !reflect.DeepEqual(data, map[string]interface{}{"tags": "{}"}) {
+		t.Errorf("Unflatten() = %v", data)
+	}
+}
+
+func TestUnflattenToJSON(t *testing.T) {
+	out, err := New().UnflattenToJSON(map[string]string{"b[0]": "x", "a.c": "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"a":{"c":"1"},"b":["x"]}`
+	if out != expected {
+		t.Errorf("UnflattenToJSON() = %s, want %s", out, expected)
+	}
+}
+
+func TestUnflattenedEncoders(t *testing.T) {
+	tree, err := New().Unflatten(map[string]string{"b[0]": "x", "a.c": "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	yamlOut, err := UnflattenedToYAML(tree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "a:\n    c: \"1\"\nb:\n    - x\n"; yamlOut != expected {
+		t.Errorf("UnflattenedToYAML() = %q, want %q", yamlOut, expected)
+	}
+
+	jsonOut, err := UnflattenedToJSON(tree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"a":{"c":"1"},"b":["x"]}`; jsonOut != expected {
+		t.Errorf("UnflattenedToJSON() = %s, want %s", jsonOut, expected)
+	}
+}
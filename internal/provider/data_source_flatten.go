@@ -2,9 +2,12 @@ package provider
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"terraform-provider-yamlflattener/internal/flattener"
 )
@@ -18,10 +21,24 @@ type flattenDataSource struct {
 }
 
 type flattenDataSourceModel struct {
-	YAMLContent types.String `tfsdk:"yaml_content"`
-	YAMLFile    types.String `tfsdk:"yaml_file"`
-	Flattened   types.Map    `tfsdk:"flattened"`
-	ID          types.String `tfsdk:"id"`
+	YAMLContent types.String  `tfsdk:"yaml_content"`
+	YAMLFile    types.String  `tfsdk:"yaml_file"`
+	YAMLFiles   types.List    `tfsdk:"yaml_files"`
+	JSONContent types.String  `tfsdk:"json_content"`
+	JSONFile    types.String  `tfsdk:"json_file"`
+	Format      types.String  `tfsdk:"format"`
+	MultiDoc    types.Bool    `tfsdk:"multi_document"`
+	DocumentKey types.String  `tfsdk:"document_key"`
+	Documents   types.List    `tfsdk:"documents"`
+	Flattened   types.Map     `tfsdk:"flattened"`
+	Ordered     types.List    `tfsdk:"ordered"`
+	Locations   types.Map     `tfsdk:"locations"`
+	Anchors     types.Map     `tfsdk:"anchors"`
+	Sources     types.Map     `tfsdk:"sources"`
+	Typed       types.Dynamic `tfsdk:"typed"`
+	ID          types.String  `tfsdk:"id"`
+	mergeOptionsModel
+	limitsModel
 }
 
 func NewFlattenDataSource() datasource.DataSource {
@@ -34,14 +51,147 @@ func (d *flattenDataSource) Metadata(_ context.Context, req datasource.MetadataR
 
 func (d *flattenDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "Flattens nested YAML structures into a map with dot notation for nested objects and bracket notation for arrays.",
+		Description: "Flattens nested YAML or JSON structures into a map with dot notation for nested objects and bracket notation for arrays.",
 		Attributes: map[string]schema.Attribute{
 			"yaml_content": schema.StringAttribute{
-				Description: "YAML content to flatten as a string. Either yaml_content or yaml_file must be provided.",
+				Description: "YAML content to flatten as a string. Exactly one of yaml_content, yaml_file, yaml_files, json_content or json_file must be provided.",
 				Optional:    true,
 			},
 			"yaml_file": schema.StringAttribute{
-				Description: "Path to a YAML file to flatten. Either yaml_content or yaml_file must be provided.",
+				Description: "Path to a file to flatten. Unless format is set, the format is detected from the file extension (.yaml, .yml, .json, .toml, .ini, .cfg, .conf, .properties) and other extensions are read as YAML. Exactly one of yaml_content, yaml_file, yaml_files, json_content or json_file must be provided.",
+				Optional:    true,
+			},
+			"yaml_files": schema.ListAttribute{
+				Description: "Paths to YAML files that are deep-merged in order before flattening, like Helm values files passed with -f: objects are merged key by key, later files win for scalars and arrays are combined as selected by array_merge. Empty files are skipped. Exactly one of yaml_content, yaml_file, yaml_files, json_content or json_file must be provided.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"array_merge": schema.StringAttribute{
+				Description: "How yaml_files combine an array present in several files: \"replace\" (the default, as Helm does), \"append\", \"merge_by_index\" or \"merge_by_key\".",
+				Optional:    true,
+			},
+			"array_merge_key": schema.StringAttribute{
+				Description: "Field that identifies the object items of an array for array_merge = \"merge_by_key\", e.g. \"name\". Items with the same value are merged, the others are appended.",
+				Optional:    true,
+			},
+			"json_content": schema.StringAttribute{
+				Description: "JSON content to flatten as a string. Numbers keep their exact text, so big integers and long decimals are not rounded. Exactly one of yaml_content, yaml_file, yaml_files, json_content or json_file must be provided.",
+				Optional:    true,
+			},
+			"json_file": schema.StringAttribute{
+				Description: "Path to a JSON file to flatten, parsed like json_content. Exactly one of yaml_content, yaml_file, yaml_files, json_content or json_file must be provided.",
+				Optional:    true,
+			},
+			"format": schema.StringAttribute{
+				Description: "Input format of yaml_content or yaml_file: \"yaml\", \"json\", \"toml\", \"ini\" or \"properties\". Defaults to detection from the yaml_file extension, or yaml. json_content and json_file are always read as JSON.",
+				Optional:    true,
+			},
+			"multi_document": schema.BoolAttribute{
+				Description: "Read every document of a multi-document YAML stream (separated by ---) instead of only the first. Keys in flattened are prefixed by the document key and each document is also returned in documents.",
+				Optional:    true,
+			},
+			"document_key": schema.StringAttribute{
+				Description: "Template for the key prefix of each document in multi-document mode, where {index} is the position of the document in the stream, counting empty documents, and any other {path} is the flattened value at that path, e.g. \"{kind}/{metadata.name}\". Defaults to that position in array notation ([0], [1], ...). Implies multi_document.",
+				Optional:    true,
+			},
+			"separator": schema.StringAttribute{
+				Description: "String placed between nested object keys. Overrides the provider default.",
+				Optional:    true,
+			},
+			"array_style": schema.StringAttribute{
+				Description: "Array index notation: \"brackets\", \"dotted\" or \"template\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"array_template": schema.StringAttribute{
+				Description: "Custom array index notation containing the {index} placeholder. Implies array_style = \"template\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"empty_collections": schema.StringAttribute{
+				Description: "How empty objects and arrays are flattened: \"omit\" leaves them out, \"literal\" keeps them as \"{}\" and \"[]\", and \"empty_string\" keeps them as empty strings. Overrides the provider default.",
+				Optional:    true,
+			},
+			"key_collisions": schema.StringAttribute{
+				Description: "What happens when two values flatten to the same key, such as \"a.b\": x next to a: {b: y}: \"error\" (the default) fails, \"warn\" keeps the first value and reports a warning, \"first_wins\" and \"last_wins\" keep the first or last value in document order, and \"escape\" escapes keys as key_escaping does, with a backslash unless key_escaping is set (a\\.b). Overrides the provider default.",
+				Optional:    true,
+			},
+			"key_escaping": schema.StringAttribute{
+				Description: "How object keys containing the separator or an array marker are written: \"none\" (the default) keeps them as they are, \"backslash\" escapes the separator, brackets and backslashes with a backslash (kubernetes\\.io/ingress\\.class) and \"quote\" wraps such keys in double quotes (\"kubernetes.io/ingress.class\"). Overrides the provider default.",
+				Optional:    true,
+			},
+			"include": schema.ListAttribute{
+				Description: "Only keep keys matching at least one pattern. Patterns are globs over flattened keys, where * matches within one key segment and ** matches any number of segments (e.g. \"database.*.host\"), or regular expressions prefixed with \"regex:\". A pattern matching an object or array includes everything below it.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"exclude": schema.ListAttribute{
+				Description: "Remove keys matching any pattern, using the same syntax as include (e.g. \"**.password\"). Excluded objects and arrays are not walked, so they do not count towards limits.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"key_transforms": schema.ListAttribute{
+				Description: "Case transforms applied in order to every key after filtering: \"upper\", \"lower\", \"snake\", \"kebab\" or \"camel\". snake, kebab and camel split keys into words at separators, array indices and camelCase boundaries, so [\"snake\", \"upper\"] turns database.primaryHost into DATABASE_PRIMARY_HOST.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"key_illegal_chars": schema.StringAttribute{
+				Description: "Regular expression matching characters to replace with key_replacement after key_transforms, e.g. \"[^A-Za-z0-9_]\".",
+				Optional:    true,
+			},
+			"key_replacement": schema.StringAttribute{
+				Description: "Replacement for characters matching key_illegal_chars. Defaults to an empty string, which removes them.",
+				Optional:    true,
+			},
+			"key_prefix": schema.StringAttribute{
+				Description: "Prefix added to every key as the last transform step, e.g. \"APP_\". Two keys that transform to the same key are reported as an error.",
+				Optional:    true,
+			},
+			"non_string_keys": schema.StringAttribute{
+				Description: "How int, float, bool, null and timestamp map keys, such as the 80 of ports: {80: http}, become key segments: \"canonical\" (the default) writes them like flattened values (ports.80, null keys as null, timestamps as RFC 3339), \"reject\" fails naming the key and \"skip\" leaves their values out. Mappings and sequences used as keys are always an error. Overrides the provider default.",
+				Optional:    true,
+			},
+			"scalar_format": schema.StringAttribute{
+				Description: "How YAML scalars are written: \"canonical\" (the default) uses one form per type (ints in base 10 at any size, floats as the shortest decimal with an exponent from 1e21 on and below 1e-6, .inf and .nan, timestamps as RFC 3339, !!binary as base64), \"preserve\" keeps the text as written in the source (0x1F, 1e21, ~). Overrides the provider default.",
+				Optional:    true,
+			},
+			"yaml_profile": schema.StringAttribute{
+				Description: "Schema deciding the type of plain YAML scalars: \"default\", \"yaml12\" (strict YAML 1.2 core schema), \"yaml11\" (YAML 1.1 types as read by Helm and Kubernetes, where yes, on, no and off are booleans) or \"strings\" (every plain scalar is a string). Overrides the provider default.",
+				Optional:    true,
+			},
+			"max_alias_expansions": schema.Int64Attribute{
+				Description: "Maximum number of YAML nodes and merged keys copied through aliases (default: 100000). Stops \"billion laughs\" documents, whose aliases expand exponentially, before they exhaust time or memory.",
+				Optional:    true,
+			},
+			"reject_aliases": schema.BoolAttribute{
+				Description: "Refuse YAML aliases (*name), including aliases in merge keys, for untrusted input. Anchors that are never referenced and inline merge keys are accepted.",
+				Optional:    true,
+			},
+			"expand_encoded": schema.BoolAttribute{
+				Description: "Flatten string values holding a JSON object or array, or a multi-line YAML mapping or sequence, into keys below their own key, e.g. a policy string '{\"Version\": \"2012-10-17\"}' yields policy.Version. Strings that do not parse stay as they are. Embedded values count towards the depth, size and alias limits of the document.",
+				Optional:    true,
+			},
+			"expand_encoded_keys": schema.ListAttribute{
+				Description: "Only expand the string values of keys matching at least one pattern, using the syntax of include (e.g. \"**.policy\"). Defaults to every string value when expand_encoded is set.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"max_depth": schema.Int64Attribute{
+				Description: "Maximum nesting depth (at most 10000). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_result_size": schema.Int64Attribute{
+				Description: "Maximum number of flattened keys (at most 10000000). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_input_size": schema.Int64Attribute{
+				Description: "Maximum size of the content or file, in bytes (at most 1 GiB). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_key_length": schema.Int64Attribute{
+				Description: "Length in bytes at which a single map key is truncated (at most 65536). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"timeout": schema.StringAttribute{
+				Description: "Maximum time spent parsing and flattening the input, as a duration such as \"30s\" (at most \"1h\", \"0s\" disables it). Overrides the provider setting.",
 				Optional:    true,
 			},
 			"flattened": schema.MapAttribute{
@@ -49,6 +199,35 @@ func (d *flattenDataSource) Schema(_ context.Context, _ datasource.SchemaRequest
 				Computed:    true,
 				ElementType: types.StringType,
 			},
+			"ordered": schema.ListAttribute{
+				Description: "The flattened key-value pairs as a list of {key, value} objects in the order they appear in the source. YAML, JSON, INI and properties input keep document order; TOML tables are sorted by key.",
+				Computed:    true,
+				ElementType: orderedElementType,
+			},
+			"locations": schema.MapAttribute{
+				Description: "The source location of every flattened key as file:line:column, or line:column for yaml_content. Only YAML input records locations; keys merged with << or copied by an alias point at the value they were copied from.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"anchors": schema.MapAttribute{
+				Description: "The name of the YAML anchor each key was copied from, for keys whose value comes from an alias (*name) or a merge key (<<: *name). Keys in the anchored value itself are not listed.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"sources": schema.MapAttribute{
+				Description: "With yaml_files, the path of the file each flattened key got its value from. Empty for other inputs.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"documents": schema.ListAttribute{
+				Description: "In multi-document mode, the flattened map of each non-empty document in stream order.",
+				Computed:    true,
+				ElementType: types.MapType{ElemType: types.StringType},
+			},
+			"typed": schema.DynamicAttribute{
+				Description: "The flattened values as an object that keeps the original value types: numbers, bools and nulls instead of strings.",
+				Computed:    true,
+			},
 			"id": schema.StringAttribute{
 				Description: "Identifier for this data source instance.",
 				Computed:    true,
@@ -74,42 +253,179 @@ func (d *flattenDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		return
 	}
 
-	if data.YAMLContent.IsNull() && data.YAMLFile.IsNull() {
-		resp.Diagnostics.AddError("Missing Required Input", "Either yaml_content or yaml_file must be provided.")
-		return
+	inputs := 0
+	for _, input := range []types.String{data.YAMLContent, data.YAMLFile, data.JSONContent, data.JSONFile} {
+		if !input.IsNull() {
+			inputs++
+		}
+	}
+	if !data.YAMLFiles.IsNull() {
+		inputs++
 	}
 
-	if !data.YAMLContent.IsNull() && !data.YAMLFile.IsNull() {
-		resp.Diagnostics.AddError("Conflicting Inputs", "Only one of yaml_content or yaml_file should be provided, not both.")
+	if inputs == 0 {
+		resp.Diagnostics.AddError("Missing Required Input", "One of yaml_content, yaml_file, yaml_files, json_content or json_file must be provided.")
 		return
 	}
 
-	f := d.flattener
-	if f == nil {
-		f = flattener.New()
+	if inputs > 1 {
+		resp.Diagnostics.AddError("Conflicting Inputs", "Only one of yaml_content, yaml_file, yaml_files, json_content or json_file should be provided.")
+		return
 	}
 
-	var flattenedMap map[string]string
-	var err error
+	format := data.Format.ValueString()
+	switch {
+	case !data.JSONContent.IsNull() || !data.JSONFile.IsNull():
+		if format != "" && format != flattener.FormatJSON {
+			resp.Diagnostics.AddError("Conflicting Inputs", fmt.Sprintf("json_content and json_file are always read as JSON, format cannot be %q.", format))
+			return
+		}
+		format = flattener.FormatJSON
+	case !data.YAMLFiles.IsNull():
+		if format != "" && format != flattener.FormatYAML {
+			resp.Diagnostics.AddError("Conflicting Inputs", fmt.Sprintf("yaml_files are always read as YAML, format cannot be %q.", format))
+			return
+		}
+		format = flattener.FormatYAML
+	case format != "":
+	case !data.YAMLFile.IsNull():
+		format = flattener.FormatYAML
+		if detected, ok := flattener.FormatForPath(data.YAMLFile.ValueString()); ok {
+			format = detected
+		}
+	default:
+		format = flattener.FormatYAML
+	}
 
-	if !data.YAMLFile.IsNull() {
-		flattenedMap, err = f.FlattenYAMLFile(data.YAMLFile.ValueString())
-	} else {
-		flattenedMap, err = f.FlattenYAMLString(data.YAMLContent.ValueString())
+	multiDoc := data.MultiDoc.ValueBool() || !data.DocumentKey.IsNull()
+	if multiDoc && format != flattener.FormatYAML {
+		resp.Diagnostics.AddError("Conflicting Inputs", "multi_document and document_key are only supported for YAML input.")
+		return
+	}
+	if multiDoc && !data.YAMLFiles.IsNull() {
+		resp.Diagnostics.AddError("Conflicting Inputs", "multi_document and document_key cannot be combined with yaml_files.")
+		return
+	}
+	if data.YAMLFiles.IsNull() && (!data.ArrayMerge.IsNull() || !data.ArrayMergeKey.IsNull()) {
+		resp.Diagnostics.AddError("Conflicting Inputs", "array_merge and array_merge_key are only supported with yaml_files.")
+		return
 	}
 
+	var options optionsModel = &data.flattenOptionsModel
+	if !data.YAMLFiles.IsNull() {
+		options = &data.mergeOptionsModel
+	}
+	f, err := withOptions(ctx, d.flattener, options)
+	if err == nil {
+		err = data.limitsModel.apply(ctx, f)
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(errorTitle(err), err.Error())
 		return
 	}
 
-	resultMap, diags := flattenedToMapValue(flattenedMap)
+	var content string
+	var files []string
+	var input path.Path
+	switch {
+	case !data.YAMLFiles.IsNull():
+		input = path.Root("yaml_files")
+		resp.Diagnostics.Append(data.YAMLFiles.ElementsAs(ctx, &files, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	case !data.YAMLContent.IsNull():
+		content, input = data.YAMLContent.ValueString(), path.Root("yaml_content")
+	case !data.JSONContent.IsNull():
+		content, input = data.JSONContent.ValueString(), path.Root("json_content")
+	case !data.YAMLFile.IsNull():
+		f.SourceFile, input = data.YAMLFile.ValueString(), path.Root("yaml_file")
+		content, err = f.ReadFile(f.SourceFile)
+	default:
+		f.SourceFile, input = data.JSONFile.ValueString(), path.Root("json_file")
+		content, err = f.ReadFile(f.SourceFile)
+	}
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic(f, flattenDataSourceCaller, input, err))
+		return
+	}
+
+	var result *flattener.Result
+	documents := types.ListNull(types.MapType{ElemType: types.StringType})
+
+	if !data.YAMLFiles.IsNull() {
+		result, err = f.FlattenFilesContext(ctx, files)
+		if err != nil {
+			resp.Diagnostics.Append(errorDiagnostic(f, flattenDataSourceCaller, input, err))
+			return
+		}
+	} else if multiDoc {
+		var docs []*flattener.Result
+		docs, result, err = f.FlattenDocumentsAndPrefixedContext(ctx, content, data.DocumentKey.ValueString())
+		if err != nil {
+			resp.Diagnostics.Append(errorDiagnostic(f, flattenDataSourceCaller, input, err))
+			return
+		}
+
+		var diags diag.Diagnostics
+		documents, diags = documentsToListValue(docs)
+		if diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+	} else {
+		result, err = f.FlattenFormatContext(ctx, content, format)
+		if err != nil {
+			resp.Diagnostics.Append(errorDiagnostic(f, flattenDataSourceCaller, input, err))
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(warningDiagnostics(input, result)...)
+
+	resultMap, diags := flattenedToMapValue(result.Values)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	ordered, diags := orderedToListValue(result.Ordered())
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	locations, diags := locationsToMapValue(result.Locations)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	anchors, diags := flattenedToMapValue(result.Anchors)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	sources, diags := flattenedToMapValue(result.Sources)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	typedObject, diags := typedToObjectValue(ctx, result)
 	if diags.HasError() {
 		resp.Diagnostics.Append(diags...)
 		return
 	}
 
 	data.Flattened = resultMap
+	data.Ordered = ordered
+	data.Locations = locations
+	data.Anchors = anchors
+	data.Sources = sources
+	data.Documents = documents
+	data.Typed = types.DynamicValue(typedObject)
 	data.ID = types.StringValue("yaml_flatten")
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
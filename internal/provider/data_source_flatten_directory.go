@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &flattenDirectoryDataSource{}
+var _ datasource.DataSourceWithConfigure = &flattenDirectoryDataSource{}
+
+type flattenDirectoryDataSource struct {
+	flattener *flattener.Flattener
+}
+
+type flattenDirectoryDataSourceModel struct {
+	Path       types.String `tfsdk:"path"`
+	Include    types.List   `tfsdk:"include"`
+	Exclude    types.List   `tfsdk:"exclude"`
+	PrefixKeys types.Bool   `tfsdk:"prefix_keys"`
+	Files      types.Map    `tfsdk:"files"`
+	Flattened  types.Map    `tfsdk:"flattened"`
+	ID         types.String `tfsdk:"id"`
+
+	MaxAliasExpansions types.Int64 `tfsdk:"max_alias_expansions"`
+	defaultOptionsModel
+	limitsModel
+}
+
+func NewFlattenDirectoryDataSource() datasource.DataSource {
+	return &flattenDirectoryDataSource{}
+}
+
+func (d *flattenDirectoryDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_flatten_directory"
+}
+
+func (d *flattenDirectoryDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Flattens every matching file below a directory, either into one map per file or into a single map whose keys are prefixed by the relative path of their file.",
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description: "Root directory to search. Every file gets the same path and size checks as yaml_file of yamlflattener_flatten.",
+				Required:    true,
+			},
+			"include": schema.ListAttribute{
+				Description: "Only flatten files whose path relative to path matches at least one pattern. Patterns are globs where * matches within one directory and ** matches any number of directories (e.g. \"services/**/*.yaml\"), or regular expressions prefixed with \"regex:\". Defaults to every file with a supported extension (.yaml, .yml, .json, .toml, .ini, .cfg, .conf, .properties).",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"exclude": schema.ListAttribute{
+				Description: "Skip files matching any pattern, using the same syntax as include (e.g. \"**/secrets/**\"). Matching directories are not searched.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"prefix_keys": schema.BoolAttribute{
+				Description: "Flatten all files into flattened, prefixing the keys of each file with its relative path without extension (e.g. \"services/api.database.host\"), instead of returning one map per file in files.",
+				Optional:    true,
+			},
+			"separator": schema.StringAttribute{
+				Description: "String placed between nested object keys. Overrides the provider default.",
+				Optional:    true,
+			},
+			"array_style": schema.StringAttribute{
+				Description: "Array index notation: \"brackets\", \"dotted\" or \"template\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"array_template": schema.StringAttribute{
+				Description: "Custom array index notation containing the {index} placeholder. Implies array_style = \"template\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"empty_collections": schema.StringAttribute{
+				Description: "How empty objects and arrays are flattened: \"omit\" leaves them out, \"literal\" keeps them as \"{}\" and \"[]\", and \"empty_string\" keeps them as empty strings. Overrides the provider default.",
+				Optional:    true,
+			},
+			"key_collisions": schema.StringAttribute{
+				Description: "What happens when two values flatten to the same key, such as \"a.b\": x next to a: {b: y}: \"error\" (the default) fails, \"warn\" keeps the first value and reports a warning, \"first_wins\" and \"last_wins\" keep the first or last value in document order, and \"escape\" escapes keys as key_escaping does, with a backslash unless key_escaping is set (a\\.b). Overrides the provider default.",
+				Optional:    true,
+			},
+			"key_escaping": schema.StringAttribute{
+				Description: "How object keys containing the separator or an array marker are written: \"none\" (the default) keeps them as they are, \"backslash\" escapes the separator, brackets and backslashes with a backslash (kubernetes\\.io/ingress\\.class) and \"quote\" wraps such keys in double quotes (\"kubernetes.io/ingress.class\"). Overrides the provider default.",
+				Optional:    true,
+			},
+			"non_string_keys": schema.StringAttribute{
+				Description: "How int, float, bool, null and timestamp map keys become key segments: \"canonical\" (the default) writes them like flattened values, \"reject\" fails naming the key and \"skip\" leaves their values out. Overrides the provider default.",
+				Optional:    true,
+			},
+			"scalar_format": schema.StringAttribute{
+				Description: "How YAML scalars are written: \"canonical\" (the default) uses one form per type and \"preserve\" keeps the text as written in the source. Overrides the provider default.",
+				Optional:    true,
+			},
+			"yaml_profile": schema.StringAttribute{
+				Description: "Schema deciding the type of plain YAML scalars: \"default\", \"yaml12\", \"yaml11\" or \"strings\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"max_depth": schema.Int64Attribute{
+				Description: "Maximum nesting depth (at most 10000). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_result_size": schema.Int64Attribute{
+				Description: "Maximum number of flattened keys across all files (at most 10000000). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_input_size": schema.Int64Attribute{
+				Description: "Maximum size of each file, in bytes (at most 1 GiB). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_key_length": schema.Int64Attribute{
+				Description: "Length in bytes at which a single map key is truncated (at most 65536). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"max_alias_expansions": schema.Int64Attribute{
+				Description: "Maximum number of YAML nodes and merged keys copied through aliases in each file, or in all files together with prefix_keys (at most 100000000). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"timeout": schema.StringAttribute{
+				Description: "Maximum time spent searching, parsing and flattening all files, as a duration such as \"30s\" (at most \"1h\", \"0s\" disables it). Overrides the provider setting.",
+				Optional:    true,
+			},
+			"files": schema.MapAttribute{
+				Description: "Unless prefix_keys is set, the flattened map of each file keyed by its path relative to path, using / as separator.",
+				Computed:    true,
+				ElementType: types.MapType{ElemType: types.StringType},
+			},
+			"flattened": schema.MapAttribute{
+				Description: "With prefix_keys, the flattened values of all files with keys prefixed by the relative path of their file.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source instance.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *flattenDirectoryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if f, ok := req.ProviderData.(*flattener.Flattener); ok {
+		d.flattener = f
+	}
+}
+
+func (d *flattenDirectoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data flattenDirectoryDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var include, exclude []string
+	if !data.Include.IsNull() {
+		resp.Diagnostics.Append(data.Include.ElementsAs(ctx, &include, false)...)
+	}
+	if !data.Exclude.IsNull() {
+		resp.Diagnostics.Append(data.Exclude.ElementsAs(ctx, &exclude, false)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	f, err := withOptions(ctx, d.flattener, &data.defaultOptionsModel)
+	if err == nil {
+		err = data.limitsModel.apply(ctx, f)
+	}
+	if err == nil {
+		err = setLimit(&f.MaxAliasExpansions, "max_alias_expansions", data.MaxAliasExpansions, 0, flattener.MaxAliasExpansionsCeiling)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(errorTitle(err), err.Error())
+		return
+	}
+
+	root := data.Path.ValueString()
+	data.Files = types.MapNull(types.MapType{ElemType: types.StringType})
+	data.Flattened = types.MapNull(types.StringType)
+
+	if data.PrefixKeys.ValueBool() {
+		result, err := f.FlattenDirectoryPrefixedContext(ctx, root, include, exclude)
+		if err != nil {
+			resp.Diagnostics.Append(errorDiagnostic(f, directoryDataSourceCaller, path.Root("path"), err))
+			return
+		}
+		resp.Diagnostics.Append(warningDiagnostics(path.Root("path"), result)...)
+		flattened, diags := flattenedToMapValue(result.Values)
+		resp.Diagnostics.Append(diags...)
+		data.Flattened = flattened
+	} else {
+		results, err := f.FlattenDirectoryContext(ctx, root, include, exclude)
+		if err != nil {
+			resp.Diagnostics.Append(errorDiagnostic(f, directoryDataSourceCaller, path.Root("path"), err))
+			return
+		}
+		elements := make(map[string]attr.Value, len(results))
+		for file, result := range results {
+			resp.Diagnostics.Append(warningDiagnostics(path.Root("path"), result)...)
+			m, diags := flattenedToMapValue(result.Values)
+			resp.Diagnostics.Append(diags...)
+			elements[file] = m
+		}
+		files, diags := types.MapValue(types.MapType{ElemType: types.StringType}, elements)
+		resp.Diagnostics.Append(diags...)
+		data.Files = files
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ID = types.StringValue("yaml_flatten_directory")
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
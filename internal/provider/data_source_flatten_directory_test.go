@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccFlattenDirectoryDataSource(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "services", "secrets"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"app.yaml":                  "name: app\n",
+		"services/api.yaml":         "database:\n  host: db\n",
+		"services/secrets/api.yaml": "password: hunter2\n",
+		"README.md":                 "# docs\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flags := t.TempDir()
+	if err := os.WriteFile(filepath.Join(flags, "app.yaml"), []byte("enabled: on\nmask: 0x1F\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases := t.TempDir()
+	if err := os.WriteFile(filepath.Join(aliases, "app.yaml"), []byte("base: &b {a: 1, b: 2}\ncopy: *b\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten_directory" "test" {
+  path    = %q
+  exclude = ["**/secrets/**"]
+}
+`, root),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "files.%", "2"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "files.app.yaml.name", "app"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "files.services/api.yaml.database.host", "db"),
+					resource.TestCheckNoResourceAttr("data.yamlflattener_flatten_directory.test", "flattened.%"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten_directory" "test" {
+  path        = %q
+  include     = ["services/**/*.yaml"]
+  prefix_keys = true
+}
+`, root),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "flattened.%", "2"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "flattened.services/api.database.host", "db"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "flattened.services/secrets/api.password", "hunter2"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+provider "yamlflattener" {
+  yaml_profile  = "yaml11"
+  scalar_format = "preserve"
+}
+
+data "yamlflattener_flatten_directory" "test" {
+  path = %q
+}
+
+data "yamlflattener_flatten_directory" "canonical" {
+  path          = %q
+  scalar_format = "canonical"
+}
+`, flags, flags),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.test", "files.app.yaml.mask", "0x1F"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.canonical", "files.app.yaml.enabled", "true"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten_directory.canonical", "files.app.yaml.mask", "31"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten_directory" "test" {
+  path                 = %q
+  max_alias_expansions = 1
+}
+`, aliases),
+				ExpectError: regexp.MustCompile(`max_alias_expansions in the provider configuration or on the\s+yamlflattener_flatten_directory data source`),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten_directory" "test" {
+  path = "config/../../etc"
+}
+`,
+				ExpectError: regexp.MustCompile(`directory traversal`),
+			},
+		},
+	})
+}
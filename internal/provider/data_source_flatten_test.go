@@ -66,6 +66,411 @@ array:
 	})
 }
 
+func TestAccFlattenDataSource_YAMLFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	base := filepath.Join(tempDir, "base.yaml")
+	prod := filepath.Join(tempDir, "prod.yaml")
+	if err := os.WriteFile(base, []byte("image:\n  repository: app\n  tag: latest\nreplicas: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(prod, []byte("image:\n  tag: v1.2.3\nreplicas: 3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten" "test" {
+  yaml_files = [%q, %q]
+}
+`, base, prod),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.image.repository", "app"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.image.tag", "v1.2.3"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.replicas", "3"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "sources.image.repository", base),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "sources.image.tag", prod),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten" "test" {
+  yaml_files     = [%q]
+  multi_document = true
+}
+`, base),
+				ExpectError: regexp.MustCompile(`cannot be combined with yaml_files`),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a: 1"
+  array_merge  = "append"
+}
+`,
+				ExpectError: regexp.MustCompile(`only supported with yaml_files`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_KeyNotation(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = <<EOT
+database:
+  replicas:
+    - host: r1
+EOT
+  separator   = "__"
+  array_style = "dotted"
+}
+`,
+				Check: resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.database__replicas__0__host", "r1"),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "items: [a]"
+  array_style  = "parens"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid Input`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_Typed(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFlattenDataSourceConfigYAMLContent(`
+server:
+  port: 8080
+  enabled: true
+`) + `
+output "port_plus_one" {
+  value = data.yamlflattener_flatten.test.typed["server.port"] + 1
+}
+
+output "enabled" {
+  value = data.yamlflattener_flatten.test.typed["server.enabled"] ? "yes" : "no"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.server.port", "8080"),
+					resource.TestCheckOutput("port_plus_one", "8081"),
+					resource.TestCheckOutput("enabled", "yes"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_MultiDocument(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = <<EOT
+kind: Service
+metadata:
+  name: web
+---
+kind: Deployment
+metadata:
+  name: web
+EOT
+  document_key = "{kind}/{metadata.name}"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.Service/web.kind", "Service"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.Deployment/web.kind", "Deployment"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "documents.#", "2"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "documents.1.kind", "Deployment"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_Filter(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = <<EOT
+database:
+  primary:
+    host: db1
+    password: secret
+  replicas:
+    - host: r1
+EOT
+  include = ["database.**"]
+  exclude = ["**.password"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.%", "2"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.database.primary.host", "db1"),
+					resource.TestCheckNoResourceAttr("data.yamlflattener_flatten.test", "flattened.database.primary.password"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_Ordered(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = <<EOT
+zeta: 1
+alpha:
+  second: b
+  first: a
+EOT
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "ordered.#", "3"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "ordered.0.key", "zeta"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "ordered.0.value", "1"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "ordered.1.key", "alpha.second"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "ordered.2.key", "alpha.first"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "locations.zeta", "1:7"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "locations.alpha.first", "4:10"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_Aliases(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = <<EOT
+defaults: &defaults
+  timeout: 30
+service:
+  <<: *defaults
+  name: api
+EOT
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.service.timeout", "30"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "anchors.%", "1"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "anchors.service.timeout", "defaults"),
+				),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content   = "a: &x 1\nb: *x\n"
+  reject_aliases = true
+}
+`,
+				ExpectError: regexp.MustCompile(`alias \*x is not allowed`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_Limits(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content    = "a: 1\nb: 2\nc: 3\n"
+  max_result_size = 2
+}
+`,
+				ExpectError: regexp.MustCompile(`The effective max_result_size is 2`),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a: 1\n"
+  max_depth    = 0
+}
+`,
+				ExpectError: regexp.MustCompile(`max_depth must be between 1 and 10000`),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a: 1\n"
+  timeout      = "30s"
+}
+`,
+				Check: resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.a", "1"),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_KeyTransforms(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = <<EOT
+database:
+  primaryHost: db1
+  replicas:
+    - host: r1
+EOT
+  key_transforms = ["snake", "upper"]
+  key_prefix     = "APP_"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.APP_DATABASE_PRIMARY_HOST", "db1"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.APP_DATABASE_REPLICAS_0_HOST", "r1"),
+				),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "db:\n  host: a\ndb_host: b\n"
+  key_transforms = ["snake"]
+}
+`,
+				ExpectError: regexp.MustCompile(`both transform to`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_JSON(t *testing.T) {
+	jsonFilePath := filepath.Join(t.TempDir(), "test.json")
+	err := os.WriteFile(jsonFilePath, []byte(`{"key": {"nested": "value"}, "items": [1, 2]}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  json_content = jsonencode({ account = { id = "1" } })
+}
+
+data "yamlflattener_flatten" "big" {
+  json_content = "{\"id\": 123456789012345678901234567890}"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.account.id", "1"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.big", "flattened.id", "123456789012345678901234567890"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten" "test" {
+  json_file = %q
+}
+`, jsonFilePath),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.key.nested", "value"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.items[1]", "2"),
+				),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  json_content = "{\"key\": }"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid JSON Syntax`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_Formats(t *testing.T) {
+	tempDir := t.TempDir()
+	tomlFilePath := filepath.Join(tempDir, "config.toml")
+	err := os.WriteFile(tomlFilePath, []byte("[server]\nport = 8080\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "yamlflattener_flatten" "toml" {
+  yaml_file = %q
+}
+
+data "yamlflattener_flatten" "ini" {
+  yaml_content = "[server]\nport = 8080\n"
+  format       = "ini"
+}
+
+data "yamlflattener_flatten" "properties" {
+  yaml_content = "server.port=8080"
+  format       = "properties"
+}
+`, tomlFilePath),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.toml", "flattened.server.port", "8080"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.ini", "flattened.server.port", "8080"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.properties", "flattened.server.port", "8080"),
+				),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a = = 1"
+  format       = "toml"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid TOML Syntax`),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a: 1"
+  format       = "xml"
+}
+`,
+				ExpectError: regexp.MustCompile(`unsupported format`),
+			},
+		},
+	})
+}
+
 func TestAccFlattenDataSource_ErrorHandling(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
@@ -77,6 +482,16 @@ func TestAccFlattenDataSource_ErrorHandling(t *testing.T) {
 		},
 	})
 
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccFlattenDataSourceConfigYAMLContent("server:\n  port: 80\n  port: 81"),
+				ExpectError: regexp.MustCompile(`duplicate mapping key "port" \(first defined on line 2\) at server\.port\s+\(line 3, column 3\)`),
+			},
+		},
+	})
+
 	resource.Test(t, resource.TestCase{
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
@@ -143,3 +558,206 @@ data "yamlflattener_flatten" "test" {
 }
 `
 }
+
+func TestAccFlattenDataSource_AllowedBaseDirs(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{allowed, outside} {
+		if err := os.WriteFile(filepath.Join(dir, "test.yaml"), []byte("key: value\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "yamlflattener" {
+  allowed_base_dirs = [%q]
+  symlink_policy    = "within_base"
+}
+
+data "yamlflattener_flatten" "test" {
+  yaml_file = %q
+}
+`, allowed, filepath.Join(allowed, "test.yaml")),
+				Check: resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.key", "value"),
+			},
+			{
+				Config: fmt.Sprintf(`
+provider "yamlflattener" {
+  allowed_base_dirs = [%q]
+}
+
+data "yamlflattener_flatten" "test" {
+  yaml_file = %q
+}
+`, allowed, filepath.Join(outside, "test.yaml")),
+				ExpectError: regexp.MustCompile(`allowed_base_dirs rule`),
+			},
+			{
+				Config: `
+provider "yamlflattener" {
+  symlink_policy = "sometimes"
+}
+
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a: 1\n"
+}
+`,
+				ExpectError: regexp.MustCompile(`unsupported symlink policy`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_ExpandEncoded(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content        = "policy: '{\"Version\": \"2012-10-17\"}'\ndata:\n  app.yaml: |\n    port: 8080\n"
+  expand_encoded      = true
+  expand_encoded_keys = ["policy"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.policy.Version", "2012-10-17"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.data.app.yaml", "port: 8080\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_KeyCollisions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "\"a.b\": dotted\na:\n  b: nested\n"
+}
+`,
+				ExpectError: regexp.MustCompile(`key "a.b" is set more than once`),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content   = "\"a.b\": dotted\na:\n  b: nested\n"
+  key_collisions = "warn"
+}
+`,
+				Check: resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.a.b", "dotted"),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_KeyEscaping(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "annotations:\n  kubernetes.io/ingress.class: nginx\n"
+  key_escaping = "quote"
+}
+`,
+				Check: resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", `flattened.annotations."kubernetes.io/ingress.class"`, "nginx"),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "a: 1\n"
+  key_escaping = "percent"
+}
+`,
+				ExpectError: regexp.MustCompile(`unsupported key escaping "percent"`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_NonStringKeys(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content = "ports:\n  80: http\ntrue: enabled\n"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.ports.80", "http"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.true", "enabled"),
+				),
+			},
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content    = "ports:\n  80: http\n"
+  non_string_keys = "reject"
+}
+`,
+				ExpectError: regexp.MustCompile(`non-string key 80`),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_ScalarFormat(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "test" {
+  yaml_content  = "mask: 0x1F\nhuge: 1e21\n"
+  scalar_format = "preserve"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.mask", "0x1F"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.huge", "1e21"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFlattenDataSource_YAMLProfile(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "yamlflattener" {
+  yaml_profile = "yaml11"
+}
+
+data "yamlflattener_flatten" "test" {
+  yaml_content = "enabled: on\nmode: 012\n"
+}
+
+data "yamlflattener_flatten" "strings" {
+  yaml_content = "enabled: on\nmode: 012\n"
+  yaml_profile = "strings"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.enabled", "true"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.test", "flattened.mode", "10"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.strings", "flattened.enabled", "on"),
+					resource.TestCheckResourceAttr("data.yamlflattener_flatten.strings", "flattened.mode", "012"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &unflattenDataSource{}
+var _ datasource.DataSourceWithConfigure = &unflattenDataSource{}
+
+type unflattenDataSource struct {
+	flattener *flattener.Flattener
+}
+
+type unflattenDataSourceModel struct {
+	Flattened types.Map    `tfsdk:"flattened"`
+	YAML      types.String `tfsdk:"yaml"`
+	JSON      types.String `tfsdk:"json"`
+	ID        types.String `tfsdk:"id"`
+	notationOptionsModel
+}
+
+func NewUnflattenDataSource() datasource.DataSource {
+	return &unflattenDataSource{}
+}
+
+func (d *unflattenDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_unflatten"
+}
+
+func (d *unflattenDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Rebuilds a nested YAML/JSON document from a flat map with dot notation for nested objects and bracket notation for arrays. The inverse of yamlflattener_flatten.",
+		Attributes: map[string]schema.Attribute{
+			"flattened": schema.MapAttribute{
+				Description: "The flattened map to rebuild, e.g. the flattened attribute of yamlflattener_flatten.",
+				Required:    true,
+				ElementType: types.StringType,
+			},
+			"separator": schema.StringAttribute{
+				Description: "String placed between nested object keys. Overrides the provider default.",
+				Optional:    true,
+			},
+			"array_style": schema.StringAttribute{
+				Description: "Array index notation: \"brackets\", \"dotted\" or \"template\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"array_template": schema.StringAttribute{
+				Description: "Custom array index notation containing the {index} placeholder. Implies array_style = \"template\". Overrides the provider default.",
+				Optional:    true,
+			},
+			"empty_collections": schema.StringAttribute{
+				Description: "With \"literal\", values \"{}\" and \"[]\" are rebuilt as empty objects and arrays, undoing empty_collections = \"literal\" of flatten. Other policies keep such values as strings. Overrides the provider default.",
+				Optional:    true,
+			},
+			"key_collisions": schema.StringAttribute{
+				Description: "With \"escape\", backslash escapes are undone as for key_escaping = \"backslash\", undoing key_collisions = \"escape\" of flatten. Overrides the provider default.",
+				Optional:    true,
+			},
+			"key_escaping": schema.StringAttribute{
+				Description: "With \"backslash\" or \"quote\", escaped and quoted object keys are read as one key, undoing key_escaping of flatten. Overrides the provider default.",
+				Optional:    true,
+			},
+			"yaml": schema.StringAttribute{
+				Description: "The rebuilt document encoded as YAML.",
+				Computed:    true,
+			},
+			"json": schema.StringAttribute{
+				Description: "The rebuilt document encoded as JSON.",
+				Computed:    true,
+			},
+			"id": schema.StringAttribute{
+				Description: "Identifier for this data source instance.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *unflattenDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if f, ok := req.ProviderData.(*flattener.Flattener); ok {
+		d.flattener = f
+	}
+}
+
+func (d *unflattenDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data unflattenDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var flat map[string]string
+	resp.Diagnostics.Append(data.Flattened.ElementsAs(ctx, &flat, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	f, err := withOptions(ctx, d.flattener, &data.notationOptionsModel)
+	if err != nil {
+		resp.Diagnostics.AddError(errorTitle(err), err.Error())
+		return
+	}
+
+	tree, err := f.Unflatten(flat)
+	if err != nil {
+		resp.Diagnostics.Append(flattenedKeyDiagnostic(f, err))
+		return
+	}
+
+	yamlOut, err := flattener.UnflattenedToYAML(tree)
+	if err != nil {
+		resp.Diagnostics.AddError(errorTitle(err), err.Error())
+		return
+	}
+
+	jsonOut, err := flattener.UnflattenedToJSON(tree)
+	if err != nil {
+		resp.Diagnostics.AddError(errorTitle(err), err.Error())
+		return
+	}
+
+	data.YAML = types.StringValue(yamlOut)
+	data.JSON = types.StringValue(jsonOut)
+	data.ID = types.StringValue("yaml_unflatten")
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// flattenedKeyDiagnostic returns an error diagnostic on the flattened key that could not
+// be unflattened, or on the whole flattened map when the key is unknown.
+func flattenedKeyDiagnostic(f *flattener.Flattener, err error) diag.Diagnostic {
+	attribute := path.Root("flattened")
+	if key := errorPath(err); key != "" {
+		attribute = attribute.AtMapKey(key)
+	}
+	return errorDiagnostic(f, unflattenCaller, attribute, err)
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccUnflattenDataSource_RoundTrip(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_flatten" "source" {
+  yaml_content = <<EOT
+database:
+  host: localhost
+  replicas:
+    - host: r1
+EOT
+}
+
+data "yamlflattener_unflatten" "test" {
+  flattened = data.yamlflattener_flatten.source.flattened
+}
+
+output "round_trip" {
+  value = provider::yamlflattener::flatten(data.yamlflattener_unflatten.test.yaml) == data.yamlflattener_flatten.source.flattened
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.yamlflattener_unflatten.test", "json", `{"database":{"host":"localhost","replicas":[{"host":"r1"}]}}`),
+					resource.TestCheckOutput("round_trip", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccUnflattenDataSource_Conflict(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "yamlflattener_unflatten" "test" {
+  flattened = {
+    "a"   = "x"
+    "a.b" = "y"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid Input`),
+			},
+		},
+	})
+}
@@ -110,3 +110,43 @@ EOT
 		},
 	})
 }
+
+func TestEquivalence_ProviderKeyNotationConfig(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "yamlflattener" {
+  separator   = "/"
+  array_style = "dotted"
+}
+
+locals {
+  yaml_content = <<EOT
+database:
+  replicas:
+    - host: r1
+EOT
+}
+
+data "yamlflattener_flatten" "ds" {
+  yaml_content = local.yaml_content
+}
+
+output "are_equal" {
+  value = jsonencode(data.yamlflattener_flatten.ds.flattened) == jsonencode(provider::yamlflattener::flatten(local.yaml_content))
+}
+
+output "function_key" {
+  value = keys(provider::yamlflattener::flatten(local.yaml_content, { separator = "__" }))[0]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("are_equal", "true"),
+					resource.TestCheckOutput("function_key", "database__replicas__0__host"),
+				),
+			},
+		},
+	})
+}
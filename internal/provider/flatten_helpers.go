@@ -1,24 +1,42 @@
 package provider
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"terraform-provider-yamlflattener/internal/flattener"
 )
 
 var errorTitles = map[flattener.ErrorType]string{
-	flattener.ErrTypeValidation:   "Invalid Input",
-	flattener.ErrTypeParsing:      "Invalid YAML Syntax",
-	flattener.ErrTypeDepthLimit:   "Nesting Depth Exceeded",
-	flattener.ErrTypeSizeLimit:    "Size Limit Exceeded",
-	flattener.ErrTypeTimeout:      "Operation Timed Out",
-	flattener.ErrTypePathSecurity: "Security Error",
-	flattener.ErrTypeFileAccess:   "File Access Error",
+	flattener.ErrTypeValidation:        "Invalid Input",
+	flattener.ErrTypeParsing:           "Invalid YAML Syntax",
+	flattener.ErrTypeJSONParsing:       "Invalid JSON Syntax",
+	flattener.ErrTypeTOMLParsing:       "Invalid TOML Syntax",
+	flattener.ErrTypeINIParsing:        "Invalid INI Syntax",
+	flattener.ErrTypePropertiesParsing: "Invalid Properties Syntax",
+	flattener.ErrTypeDepthLimit:        "Nesting Depth Exceeded",
+	flattener.ErrTypeSizeLimit:         "Size Limit Exceeded",
+	flattener.ErrTypeAlias:             "YAML Alias Error",
+	flattener.ErrTypeTimeout:           "Operation Timed Out",
+	flattener.ErrTypeCancelled:         "Operation Cancelled",
+	flattener.ErrTypePathSecurity:      "Security Error",
+	flattener.ErrTypeFileAccess:        "File Access Error",
 }
 
+// flattenOptionsDescription describes the options object of the functions that flatten YAML.
+const flattenOptionsDescription = "Optional object of flattening options overriding the provider defaults: separator, array_style (brackets, dotted or template), array_template, empty_collections (omit, literal or empty_string), key_collisions (error, warn, first_wins, last_wins or escape), key_escaping (none, backslash or quote), include and exclude key pattern lists, key_transforms (upper, lower, snake, kebab, camel), key_illegal_chars, key_replacement, key_prefix, non_string_keys (canonical, reject or skip), scalar_format (canonical or preserve), yaml_profile (default, yaml12, yaml11 or strings), the YAML alias limits max_alias_expansions and reject_aliases, and expand_encoded with the expand_encoded_keys pattern list to flatten JSON or YAML held in string values"
+
+// flattenJSONOptionsDescription describes the options object of flatten_json, which leaves
+// out the options that only apply to YAML input.
+const flattenJSONOptionsDescription = "Optional object of flattening options overriding the provider defaults: separator, array_style (brackets, dotted or template), array_template, empty_collections (omit, literal or empty_string), key_collisions (error, warn, first_wins, last_wins or escape), key_escaping (none, backslash or quote), include and exclude key pattern lists, key_transforms (upper, lower, snake, kebab, camel), key_illegal_chars, key_replacement, key_prefix, and expand_encoded with the expand_encoded_keys pattern list to flatten JSON or YAML held in string values"
+
 // errorTitle returns a human-readable title for a flattener error, or "Flatten Error" for unknown errors.
 func errorTitle(err error) string {
 	var fe *flattener.Error
@@ -30,6 +48,116 @@ func errorTitle(err error) string {
 	return "Flatten Error"
 }
 
+// errorCaller describes the data source or function an error is reported by, so that
+// limit errors point at the attributes that caller can override.
+type errorCaller struct {
+	// where names the place limits are overridden, e.g. "on the yamlflattener_flatten data source"
+	where string
+	// limits lists the limit attributes that can be overridden there
+	limits []string
+}
+
+var (
+	flattenDataSourceCaller = errorCaller{
+		where:  "on the yamlflattener_flatten data source",
+		limits: []string{"max_depth", "max_result_size", "max_input_size", "max_alias_expansions", "timeout"},
+	}
+	directoryDataSourceCaller = errorCaller{
+		where:  "on the yamlflattener_flatten_directory data source",
+		limits: []string{"max_depth", "max_result_size", "max_input_size", "max_alias_expansions", "timeout"},
+	}
+	// unflattenCaller is the unflatten data source and function, which override no limits
+	unflattenCaller = errorCaller{}
+)
+
+// functionCaller returns the errorCaller of a flatten function, whose options object
+// overrides max_alias_expansions.
+func functionCaller(name string) errorCaller {
+	return errorCaller{where: "in the options of the " + name + " function", limits: []string{"max_alias_expansions"}}
+}
+
+// errorDiagnostic returns an error diagnostic for err on the attribute it came from. The
+// detail names the offending key and its source position when the flattener knows them.
+func errorDiagnostic(f *flattener.Flattener, caller errorCaller, attribute path.Path, err error) diag.Diagnostic {
+	return diag.NewAttributeErrorDiagnostic(attribute, errorTitle(err), errorDetail(f, caller, err))
+}
+
+// warningDiagnostics returns a warning on the input attribute for every key collision
+// the flattener resolved under the "warn" policy.
+func warningDiagnostics(attribute path.Path, r *flattener.Result) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, warning := range r.Warnings {
+		diags.AddAttributeWarning(attribute, "Key Collision", warning)
+	}
+	return diags
+}
+
+// limitAttributes maps the Flattener limit settings to the attributes that configure them.
+var limitAttributes = map[string]string{
+	"MaxNestingDepth":    "max_depth",
+	"MaxResultSize":      "max_result_size",
+	"MaxYAMLSize":        "max_input_size",
+	"MaxAliasExpansions": "max_alias_expansions",
+	"Timeout":            "timeout",
+}
+
+// ruleAttributes maps the path rules configured on the provider to their attributes.
+var ruleAttributes = map[string]string{
+	flattener.PathRuleBaseDir: "allowed_base_dirs",
+	flattener.PathRuleSymlink: "symlink_policy",
+}
+
+// errorDetail returns the message of err. When a limit was exceeded it adds the
+// effective value of the limit and where caller can raise it, and when a path rule
+// rejected a file the provider attribute that configures the rule.
+func errorDetail(f *flattener.Flattener, caller errorCaller, err error) string {
+	var fe *flattener.Error
+	if !errors.As(err, &fe) || f == nil {
+		return err.Error()
+	}
+	if attribute, ok := ruleAttributes[fe.Rule]; ok {
+		return fmt.Sprintf("%s\n\nThe file was rejected by the %s rule, configured with %s in the provider configuration.",
+			err.Error(), fe.Rule, attribute)
+	}
+	attribute, ok := limitAttributes[fe.Limit]
+	if !ok {
+		return err.Error()
+	}
+	where := "in the provider configuration"
+	for _, limit := range caller.limits {
+		if limit == attribute {
+			where += " or " + caller.where
+		}
+	}
+	return fmt.Sprintf("%s\n\nThe effective %s is %s. Set %s %s to change it.",
+		err.Error(), attribute, limitValue(f, fe.Limit), attribute, where)
+}
+
+// limitValue renders the current value of a Flattener limit setting.
+func limitValue(f *flattener.Flattener, setting string) string {
+	switch setting {
+	case "MaxNestingDepth":
+		return strconv.Itoa(f.MaxNestingDepth)
+	case "MaxResultSize":
+		return strconv.Itoa(f.MaxResultSize)
+	case "MaxYAMLSize":
+		return strconv.Itoa(f.MaxYAMLSize)
+	case "MaxAliasExpansions":
+		return strconv.Itoa(f.MaxAliasExpansions)
+	default:
+		return fmt.Sprintf("%q", f.Timeout.String())
+	}
+}
+
+// errorPath returns the flattened key at which a flattener error occurred, or "" if unknown.
+func errorPath(err error) string {
+	var fe *flattener.Error
+	if errors.As(err, &fe) {
+		return fe.Path
+	}
+	return ""
+}
+
 // flattenedToMapValue converts a map[string]string to a Terraform types.Map.
 func flattenedToMapValue(m map[string]string) (types.Map, diag.Diagnostics) {
 	elements := make(map[string]attr.Value, len(m))
@@ -38,3 +166,92 @@ func flattenedToMapValue(m map[string]string) (types.Map, diag.Diagnostics) {
 	}
 	return types.MapValue(types.StringType, elements)
 }
+
+// locationsToMapValue converts source locations to a Terraform map of file:line:column strings.
+func locationsToMapValue(locations map[string]flattener.Location) (types.Map, diag.Diagnostics) {
+	elements := make(map[string]attr.Value, len(locations))
+	for k, l := range locations {
+		elements[k] = types.StringValue(l.String())
+	}
+	return types.MapValue(types.StringType, elements)
+}
+
+// orderedElementType is the element type of ordered results: one object per flattened key.
+var orderedElementType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"key":   types.StringType,
+	"value": types.StringType,
+}}
+
+// orderedToListValue converts flattened key-value pairs to a Terraform list of {key, value}
+// objects, keeping their order.
+func orderedToListValue(pairs []flattener.KeyValue) (types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	elements := make([]attr.Value, 0, len(pairs))
+	for _, pair := range pairs {
+		object, d := types.ObjectValue(orderedElementType.AttrTypes, map[string]attr.Value{
+			"key":   types.StringValue(pair.Key),
+			"value": types.StringValue(pair.Value),
+		})
+		diags.Append(d...)
+		elements = append(elements, object)
+	}
+	if diags.HasError() {
+		return types.ListNull(orderedElementType), diags
+	}
+	list, d := types.ListValue(orderedElementType, elements)
+	diags.Append(d...)
+	return list, diags
+}
+
+// documentsToListValue converts the flattened documents of a multi-document stream to a Terraform list of maps.
+func documentsToListValue(docs []*flattener.Result) (types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	elements := make([]attr.Value, 0, len(docs))
+	for _, doc := range docs {
+		m, d := flattenedToMapValue(doc.Values)
+		diags.Append(d...)
+		elements = append(elements, m)
+	}
+	if diags.HasError() {
+		return types.ListNull(types.MapType{ElemType: types.StringType}), diags
+	}
+	list, d := types.ListValue(types.MapType{ElemType: types.StringType}, elements)
+	diags.Append(d...)
+	return list, diags
+}
+
+// typedToObjectValue converts a flattener result to a Terraform object whose attributes keep
+// the original value types: numbers, bools and nulls instead of strings.
+func typedToObjectValue(ctx context.Context, r *flattener.Result) (types.Object, diag.Diagnostics) {
+	attrTypes := make(map[string]attr.Type, len(r.Values))
+	attrValues := make(map[string]attr.Value, len(r.Values))
+	for k, v := range r.Values {
+		value := typedValue(v, r.Types[k])
+		attrTypes[k] = value.Type(ctx)
+		attrValues[k] = value
+	}
+	return types.ObjectValue(attrTypes, attrValues)
+}
+
+// typedValue converts a flattened string back to a Terraform value of the given type.
+// Numbers Terraform cannot represent, such as infinity, stay strings.
+func typedValue(v string, t flattener.ValueType) attr.Value {
+	switch t {
+	case flattener.ValueTypeNumber:
+		if n, ok := new(big.Float).SetPrec(512).SetString(v); ok && !n.IsInf() {
+			return types.NumberValue(n)
+		}
+	case flattener.ValueTypeBool:
+		// scalar_format = "preserve" keeps bools as written, such as True
+		if b, err := strconv.ParseBool(v); err == nil {
+			return types.BoolValue(b)
+		}
+	case flattener.ValueTypeNull:
+		return types.StringNull()
+	case flattener.ValueTypeObject:
+		return types.ObjectValueMust(map[string]attr.Type{}, map[string]attr.Value{})
+	case flattener.ValueTypeArray:
+		return types.TupleValueMust([]attr.Type{}, []attr.Value{})
+	}
+	return types.StringValue(v)
+}
@@ -0,0 +1,418 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+// notationOptionsModel holds the key notation options shared by flatten and unflatten,
+// which can be set as provider defaults and overridden per data source or function call.
+type notationOptionsModel struct {
+	Separator        types.String `tfsdk:"separator"`
+	ArrayStyle       types.String `tfsdk:"array_style"`
+	ArrayTemplate    types.String `tfsdk:"array_template"`
+	EmptyCollections types.String `tfsdk:"empty_collections"`
+	KeyCollisions    types.String `tfsdk:"key_collisions"`
+	KeyEscaping      types.String `tfsdk:"key_escaping"`
+}
+
+// defaultOptionsModel holds the flattening options that can be set as provider
+// defaults and overridden per data source or function call: the key notation plus
+// how YAML scalars and keys are read.
+type defaultOptionsModel struct {
+	notationOptionsModel
+	NonStringKeys types.String `tfsdk:"non_string_keys"`
+	ScalarFormat  types.String `tfsdk:"scalar_format"`
+	YAMLProfile   types.String `tfsdk:"yaml_profile"`
+}
+
+// flattenOptionsModel holds every option of a single flatten call: the provider
+// defaults plus options that only make sense for one document.
+type flattenOptionsModel struct {
+	defaultOptionsModel
+	Include         types.List   `tfsdk:"include"`
+	Exclude         types.List   `tfsdk:"exclude"`
+	KeyTransforms   types.List   `tfsdk:"key_transforms"`
+	KeyIllegalChars types.String `tfsdk:"key_illegal_chars"`
+	KeyReplacement  types.String `tfsdk:"key_replacement"`
+	KeyPrefix       types.String `tfsdk:"key_prefix"`
+
+	MaxAliasExpansions types.Int64 `tfsdk:"max_alias_expansions"`
+	RejectAliases      types.Bool  `tfsdk:"reject_aliases"`
+
+	ExpandEncoded     types.Bool `tfsdk:"expand_encoded"`
+	ExpandEncodedKeys types.List `tfsdk:"expand_encoded_keys"`
+}
+
+// jsonOptionsModel holds the options of a JSON flatten call: the flatten options
+// without those that only apply to YAML input, which are rejected as unknown.
+type jsonOptionsModel struct {
+	flattenOptionsModel
+}
+
+// yamlOnlyOptions names the options that jsonOptionsModel leaves out.
+var yamlOnlyOptions = []string{"non_string_keys", "scalar_format", "yaml_profile", "max_alias_expansions", "reject_aliases"}
+
+// mergeOptionsModel holds the options of a layered merge: the flatten options plus how
+// arrays present in several layers are combined.
+type mergeOptionsModel struct {
+	flattenOptionsModel
+	ArrayMerge    types.String `tfsdk:"array_merge"`
+	ArrayMergeKey types.String `tfsdk:"array_merge_key"`
+}
+
+// limitsModel holds the limits that can be set on the provider and overridden per data
+// source.
+type limitsModel struct {
+	MaxDepth      types.Int64  `tfsdk:"max_depth"`
+	MaxResultSize types.Int64  `tfsdk:"max_result_size"`
+	MaxInputSize  types.Int64  `tfsdk:"max_input_size"`
+	MaxKeyLength  types.Int64  `tfsdk:"max_key_length"`
+	Timeout       types.String `tfsdk:"timeout"`
+}
+
+// optionsModel is implemented by the option models so they can be applied to a Flattener.
+type optionsModel interface {
+	apply(ctx context.Context, f *flattener.Flattener) error
+	fields() map[string]optionField
+}
+
+// apply copies every option that is set onto the Flattener and validates the result.
+func (o *notationOptionsModel) apply(_ context.Context, f *flattener.Flattener) error {
+	if !o.Separator.IsNull() {
+		f.Separator = o.Separator.ValueString()
+	}
+	if !o.ArrayStyle.IsNull() {
+		f.ArrayStyle = flattener.ArrayStyle(o.ArrayStyle.ValueString())
+	}
+	if !o.ArrayTemplate.IsNull() {
+		f.ArrayTemplate = o.ArrayTemplate.ValueString()
+		if o.ArrayStyle.IsNull() {
+			f.ArrayStyle = flattener.ArrayStyleTemplate
+		}
+	}
+	if !o.EmptyCollections.IsNull() {
+		f.EmptyCollections = flattener.EmptyCollections(o.EmptyCollections.ValueString())
+	}
+	if !o.KeyCollisions.IsNull() {
+		f.KeyCollisions = flattener.KeyCollisions(o.KeyCollisions.ValueString())
+	}
+	if !o.KeyEscaping.IsNull() {
+		f.KeyEscaping = flattener.KeyEscaping(o.KeyEscaping.ValueString())
+	}
+	return f.Validate()
+}
+
+func (o *notationOptionsModel) fields() map[string]optionField {
+	return map[string]optionField{
+		"separator":         stringField(&o.Separator),
+		"array_style":       stringField(&o.ArrayStyle),
+		"array_template":    stringField(&o.ArrayTemplate),
+		"empty_collections": stringField(&o.EmptyCollections),
+		"key_collisions":    stringField(&o.KeyCollisions),
+		"key_escaping":      stringField(&o.KeyEscaping),
+	}
+}
+
+// apply copies every option that is set onto the Flattener and validates the result.
+func (o *defaultOptionsModel) apply(ctx context.Context, f *flattener.Flattener) error {
+	if !o.NonStringKeys.IsNull() {
+		f.NonStringKeys = flattener.NonStringKeys(o.NonStringKeys.ValueString())
+	}
+	if !o.ScalarFormat.IsNull() {
+		f.ScalarFormat = flattener.ScalarFormat(o.ScalarFormat.ValueString())
+	}
+	if !o.YAMLProfile.IsNull() {
+		f.YAMLProfile = flattener.YAMLProfile(o.YAMLProfile.ValueString())
+	}
+	return o.notationOptionsModel.apply(ctx, f)
+}
+
+func (o *defaultOptionsModel) fields() map[string]optionField {
+	fields := o.notationOptionsModel.fields()
+	fields["non_string_keys"] = stringField(&o.NonStringKeys)
+	fields["scalar_format"] = stringField(&o.ScalarFormat)
+	fields["yaml_profile"] = stringField(&o.YAMLProfile)
+	return fields
+}
+
+// apply copies every option that is set onto the Flattener and validates the result.
+func (o *flattenOptionsModel) apply(ctx context.Context, f *flattener.Flattener) error {
+	if !o.Include.IsNull() {
+		if diags := o.Include.ElementsAs(ctx, &f.Include, false); diags.HasError() {
+			return fmt.Errorf("invalid include patterns: %s", diags[0].Detail())
+		}
+	}
+	if !o.Exclude.IsNull() {
+		if diags := o.Exclude.ElementsAs(ctx, &f.Exclude, false); diags.HasError() {
+			return fmt.Errorf("invalid exclude patterns: %s", diags[0].Detail())
+		}
+	}
+	if !o.KeyTransforms.IsNull() {
+		if diags := o.KeyTransforms.ElementsAs(ctx, &f.KeyTransforms, false); diags.HasError() {
+			return fmt.Errorf("invalid key transforms: %s", diags[0].Detail())
+		}
+	}
+	if !o.KeyIllegalChars.IsNull() {
+		f.KeyIllegalChars = o.KeyIllegalChars.ValueString()
+	}
+	if !o.KeyReplacement.IsNull() {
+		f.KeyReplacement = o.KeyReplacement.ValueString()
+	}
+	if !o.KeyPrefix.IsNull() {
+		f.KeyPrefix = o.KeyPrefix.ValueString()
+	}
+	if err := setLimit(&f.MaxAliasExpansions, "max_alias_expansions", o.MaxAliasExpansions, 0, flattener.MaxAliasExpansionsCeiling); err != nil {
+		return err
+	}
+	if !o.RejectAliases.IsNull() {
+		f.RejectAliases = o.RejectAliases.ValueBool()
+	}
+	if !o.ExpandEncoded.IsNull() {
+		f.ExpandEncoded = o.ExpandEncoded.ValueBool()
+	}
+	if !o.ExpandEncodedKeys.IsNull() {
+		if diags := o.ExpandEncodedKeys.ElementsAs(ctx, &f.ExpandEncodedKeys, false); diags.HasError() {
+			return fmt.Errorf("invalid expand encoded keys: %s", diags[0].Detail())
+		}
+	}
+	return o.defaultOptionsModel.apply(ctx, f)
+}
+
+func (o *flattenOptionsModel) fields() map[string]optionField {
+	fields := o.defaultOptionsModel.fields()
+	fields["include"] = stringListField(&o.Include)
+	fields["exclude"] = stringListField(&o.Exclude)
+	fields["key_transforms"] = stringListField(&o.KeyTransforms)
+	fields["key_illegal_chars"] = stringField(&o.KeyIllegalChars)
+	fields["key_replacement"] = stringField(&o.KeyReplacement)
+	fields["key_prefix"] = stringField(&o.KeyPrefix)
+	fields["max_alias_expansions"] = int64Field(&o.MaxAliasExpansions)
+	fields["reject_aliases"] = boolField(&o.RejectAliases)
+	fields["expand_encoded"] = boolField(&o.ExpandEncoded)
+	fields["expand_encoded_keys"] = stringListField(&o.ExpandEncodedKeys)
+	return fields
+}
+
+func (o *jsonOptionsModel) fields() map[string]optionField {
+	fields := o.flattenOptionsModel.fields()
+	for _, name := range yamlOnlyOptions {
+		delete(fields, name)
+	}
+	return fields
+}
+
+// apply copies every option that is set onto the Flattener and validates the result.
+func (o *mergeOptionsModel) apply(ctx context.Context, f *flattener.Flattener) error {
+	if !o.ArrayMerge.IsNull() {
+		f.ArrayMerge = flattener.ArrayMerge(o.ArrayMerge.ValueString())
+	}
+	if !o.ArrayMergeKey.IsNull() {
+		f.ArrayMergeKey = o.ArrayMergeKey.ValueString()
+	}
+	return o.flattenOptionsModel.apply(ctx, f)
+}
+
+func (o *mergeOptionsModel) fields() map[string]optionField {
+	fields := o.flattenOptionsModel.fields()
+	fields["array_merge"] = stringField(&o.ArrayMerge)
+	fields["array_merge_key"] = stringField(&o.ArrayMergeKey)
+	return fields
+}
+
+// apply copies every limit that is set onto the Flattener, checking it against the
+// bounds accepted by the flattener.
+func (l *limitsModel) apply(_ context.Context, f *flattener.Flattener) error {
+	if err := setLimit(&f.MaxNestingDepth, "max_depth", l.MaxDepth, 1, flattener.MaxNestingDepthCeiling); err != nil {
+		return err
+	}
+	if err := setLimit(&f.MaxResultSize, "max_result_size", l.MaxResultSize, 1, flattener.MaxResultSizeCeiling); err != nil {
+		return err
+	}
+	if err := setLimit(&f.MaxYAMLSize, "max_input_size", l.MaxInputSize, 1, flattener.MaxYAMLSizeCeiling); err != nil {
+		return err
+	}
+	if err := setLimit(&f.MaxKeyLength, "max_key_length", l.MaxKeyLength, 1, flattener.MaxKeyLengthCeiling); err != nil {
+		return err
+	}
+	if !l.Timeout.IsNull() {
+		timeout, err := parseTimeout(l.Timeout.ValueString())
+		if err != nil {
+			return err
+		}
+		f.Timeout = timeout
+	}
+	return f.Validate()
+}
+
+// setLimit copies a limit attribute onto dst if it is set and within [min, max].
+func setLimit(dst *int, name string, value types.Int64, minimum, maximum int64) error {
+	if value.IsNull() {
+		return nil
+	}
+	n := value.ValueInt64()
+	if n < minimum || n > maximum {
+		return fmt.Errorf("%s must be between %d and %d, got %d", name, minimum, maximum, n)
+	}
+	*dst = int(n)
+	return nil
+}
+
+// parseTimeout parses a timeout attribute as a Go duration between 0 and the flattener's
+// ceiling.
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q, expected a duration such as \"30s\": %w", value, err)
+	}
+	if timeout < 0 || timeout > flattener.TimeoutCeiling {
+		return 0, fmt.Errorf("timeout must be between 0s and %s, got %q", flattener.TimeoutCeiling, value)
+	}
+	return timeout, nil
+}
+
+// withOptions returns a copy of base (or a default Flattener when base is nil)
+// with the options applied, leaving base untouched.
+func withOptions(ctx context.Context, base *flattener.Flattener, o optionsModel) (*flattener.Flattener, error) {
+	f := flattener.New()
+	if base != nil {
+		clone := *base
+		f = &clone
+	}
+	if err := o.apply(ctx, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// optionField decodes one attribute of a function options object into an options model field.
+type optionField func(ctx context.Context, value attr.Value) error
+
+func stringField(dst *types.String) optionField {
+	return func(_ context.Context, value attr.Value) error {
+		s, ok := value.(types.String)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		*dst = s
+		return nil
+	}
+}
+
+func int64Field(dst *types.Int64) optionField {
+	return func(_ context.Context, value attr.Value) error {
+		n, ok := value.(types.Number)
+		if !ok {
+			return fmt.Errorf("must be a number")
+		}
+		i, accuracy := n.ValueBigFloat().Int64()
+		if accuracy != big.Exact {
+			return fmt.Errorf("must be a whole number")
+		}
+		*dst = types.Int64Value(i)
+		return nil
+	}
+}
+
+func boolField(dst *types.Bool) optionField {
+	return func(_ context.Context, value attr.Value) error {
+		b, ok := value.(types.Bool)
+		if !ok {
+			return fmt.Errorf("must be a bool")
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func stringListField(dst *types.List) optionField {
+	return func(_ context.Context, value attr.Value) error {
+		var elements []attr.Value
+		switch v := value.(type) {
+		case types.List:
+			elements = v.Elements()
+		case types.Tuple:
+			elements = v.Elements()
+		case types.Set:
+			elements = v.Elements()
+		default:
+			return fmt.Errorf("must be a list of strings")
+		}
+		for _, e := range elements {
+			if _, ok := e.(types.String); !ok {
+				return fmt.Errorf("must be a list of strings")
+			}
+		}
+		list, diags := types.ListValue(types.StringType, elements)
+		if diags.HasError() {
+			return fmt.Errorf("must be a list of strings")
+		}
+		*dst = list
+		return nil
+	}
+}
+
+// functionOptions decodes the optional trailing options object of a function call into
+// the given options model. Terraform object and map literals are both accepted; unknown
+// option names are rejected.
+func functionOptions(ctx context.Context, args []types.Dynamic, o optionsModel) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("at most one options object may be given, got %d", len(args))
+	}
+
+	attrs, err := optionAttributes(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	fields := o.fields()
+	for name, value := range attrs {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unsupported option %q, expected one of: %s", name, strings.Join(sortedNames(fields), ", "))
+		}
+		if value.IsNull() {
+			continue
+		}
+		if err := field(ctx, value); err != nil {
+			return fmt.Errorf("option %q %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// optionAttributes returns the attributes of an options argument given as an object or map.
+func optionAttributes(ctx context.Context, arg types.Dynamic) (map[string]attr.Value, error) {
+	if arg.IsNull() || arg.IsUnderlyingValueNull() {
+		return nil, nil
+	}
+	switch v := arg.UnderlyingValue().(type) {
+	case types.Object:
+		return v.Attributes(), nil
+	case types.Map:
+		return v.Elements(), nil
+	default:
+		return nil, fmt.Errorf("options must be an object, got %s", v.Type(ctx))
+	}
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &encodeFunction{}
+
+// encodeFunction renders a flattened map as a file in one output format.
+type encodeFunction struct {
+	name        string
+	summary     string
+	description string
+	encode      func(map[string]string) (string, error)
+}
+
+// NewToDotenvFunction creates a new to_dotenv function.
+func NewToDotenvFunction() function.Function {
+	return &encodeFunction{
+		name:        "to_dotenv",
+		summary:     "Encode a flattened map as a dotenv file",
+		description: "Takes a flat map (the output of flatten) and returns a dotenv file with one KEY=value line per key, sorted by key. Values other than plain words are double-quoted with backslashes, double quotes, backticks and $ escaped and newlines written as \\n. Keys containing whitespace, =, quotes, $, \\ or # are rejected.",
+		encode:      flattener.EncodeDotenv,
+	}
+}
+
+// NewToPropertiesFunction creates a new to_properties function.
+func NewToPropertiesFunction() function.Function {
+	return &encodeFunction{
+		name:        "to_properties",
+		summary:     "Encode a flattened map as a Java properties file",
+		description: "Takes a flat map (the output of flatten) and returns a Java .properties file with one key=value line per key, sorted by key. Backslashes, =, :, # and ! are escaped, control characters are written as \\t, \\n, \\r and \\f, and characters outside printable ASCII as \\uXXXX.",
+		encode:      flattener.EncodeProperties,
+	}
+}
+
+// NewToJSONFunction creates a new to_json function.
+func NewToJSONFunction() function.Function {
+	return &encodeFunction{
+		name:        "to_json",
+		summary:     "Encode a flattened map as a JSON object",
+		description: "Takes a flat map (the output of flatten) and returns an indented JSON object with sorted keys, keeping the flattened keys as they are. Unlike jsonencode, HTML characters such as < and & are not escaped.",
+		encode:      flattener.EncodeJSON,
+	}
+}
+
+func (fn *encodeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = fn.name
+}
+
+func (fn *encodeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     fn.summary,
+		Description: fn.description,
+		Parameters: []function.Parameter{
+			function.MapParameter{
+				Name:        "flattened",
+				Description: "The flattened map to encode",
+				ElementType: types.StringType,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (fn *encodeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var flat map[string]string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &flat))
+	if resp.Error != nil {
+		return
+	}
+
+	encoded, err := fn.encode(flat)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	resp.Result = function.NewResultData(types.StringValue(encoded))
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEncodeFunctions_Run(t *testing.T) {
+	flat := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"db.port": types.StringValue("5432"),
+		"db.host": types.StringValue("db:1"),
+	})
+
+	tests := []struct {
+		name     string
+		fn       function.Function
+		expected string
+	}{
+		{
+			name:     "to_dotenv",
+			fn:       NewToDotenvFunction(),
+			expected: "db.host=db:1\ndb.port=5432\n",
+		},
+		{
+			name:     "to_properties",
+			fn:       NewToPropertiesFunction(),
+			expected: "db.host=db\\:1\ndb.port=5432\n",
+		},
+		{
+			name:     "to_json",
+			fn:       NewToJSONFunction(),
+			expected: "{\n  \"db.host\": \"db:1\",\n  \"db.port\": \"5432\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &function.MetadataResponse{}
+			tt.fn.Metadata(context.Background(), function.MetadataRequest{}, metadata)
+			if metadata.Name != tt.name {
+				t.Errorf("Expected function name %q, got %s", tt.name, metadata.Name)
+			}
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.StringNull())}
+			tt.fn.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{flat}),
+			}, resp)
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if got := resp.Result.Value(); !got.Equal(types.StringValue(tt.expected)) {
+				t.Errorf("result = %v, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToDotenvFunction_Run_InvalidKey(t *testing.T) {
+	resp := &function.RunResponse{}
+	NewToDotenvFunction().Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.MapValueMust(types.StringType, map[string]attr.Value{"bad key": types.StringValue("x")}),
+		}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Fatal("Expected error for invalid dotenv key, got nil")
+	}
+	if !strings.Contains(resp.Error.Error(), "Invalid Input") {
+		t.Errorf("Expected Invalid Input error, got %s", resp.Error)
+	}
+}
@@ -26,13 +26,17 @@ func (fn *flattenFunction) Metadata(_ context.Context, _ function.MetadataReques
 func (fn *flattenFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
 	resp.Definition = function.Definition{
 		Summary:     "Flatten nested YAML content into a map with dot notation",
-		Description: "Takes YAML content as input and returns a flattened map where nested objects use dot notation (e.g., 'parent.child') and arrays use bracket notation (e.g., 'parent.array[0]').",
+		Description: "Takes YAML content as input and returns a flattened map where nested objects use dot notation (e.g., 'parent.child') and arrays use bracket notation (e.g., 'parent.array[0]'). An optional options object changes the separator and array notation, filters keys and transforms them.",
 		Parameters: []function.Parameter{
 			function.StringParameter{
 				Name:        "yaml_content",
 				Description: "The YAML content to flatten as a string",
 			},
 		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: flattenOptionsDescription,
+		},
 		Return: function.MapReturn{
 			ElementType: types.StringType,
 		},
@@ -41,20 +45,28 @@ func (fn *flattenFunction) Definition(_ context.Context, _ function.DefinitionRe
 
 func (fn *flattenFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
 	var yamlContent string
+	var options []types.Dynamic
 
-	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &yamlContent))
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &yamlContent, &options))
 	if resp.Error != nil {
 		return
 	}
 
-	f := fn.flattener
-	if f == nil {
-		f = flattener.New()
+	var opts flattenOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
 	}
 
-	flattenedMap, err := f.FlattenYAMLString(yamlContent)
+	flattenedMap, err := f.FlattenYAMLStringContext(ctx, yamlContent)
 	if err != nil {
-		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+err.Error()))
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten"), err)))
 		return
 	}
 
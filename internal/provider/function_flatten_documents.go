@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &flattenDocumentsFunction{}
+
+type flattenDocumentsFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewFlattenDocumentsFunction creates a new flatten_documents function with the given Flattener. Falls back to defaults if nil.
+func NewFlattenDocumentsFunction(f *flattener.Flattener) function.Function {
+	return &flattenDocumentsFunction{flattener: f}
+}
+
+func (fn *flattenDocumentsFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "flatten_documents"
+}
+
+func (fn *flattenDocumentsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Flatten every document of a multi-document YAML stream",
+		Description: "Takes a YAML stream whose documents are separated by '---' and returns a list with the flattened map of each non-empty document, in stream order. An optional options object changes the separator and array notation, filters keys and transforms them.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "yaml_content",
+				Description: "The multi-document YAML content to flatten as a string",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: flattenOptionsDescription,
+		},
+		Return: function.ListReturn{
+			ElementType: types.MapType{ElemType: types.StringType},
+		},
+	}
+}
+
+func (fn *flattenDocumentsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var yamlContent string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &yamlContent, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts flattenOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	docs, err := f.FlattenDocumentsContext(ctx, yamlContent)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten_documents"), err)))
+		return
+	}
+
+	resultList, diags := documentsToListValue(docs)
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result list: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(resultList)
+}
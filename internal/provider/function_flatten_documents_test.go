@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFlattenDocumentsFunction_Metadata(t *testing.T) {
+	f := NewFlattenDocumentsFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "flatten_documents" {
+		t.Errorf("Expected function name 'flatten_documents', got %s", resp.Name)
+	}
+}
+
+func TestFlattenDocumentsFunction_Run(t *testing.T) {
+	f := NewFlattenDocumentsFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.ListNull(types.MapType{ElemType: types.StringType}))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("kind: Service\n---\n---\nkind: Deployment\n"), noOptions}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := types.ListValueMust(types.MapType{ElemType: types.StringType}, []attr.Value{
+		types.MapValueMust(types.StringType, map[string]attr.Value{"kind": types.StringValue("Service")}),
+		types.MapValueMust(types.StringType, map[string]attr.Value{"kind": types.StringValue("Deployment")}),
+	})
+	if !resp.Result.Value().Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+	}
+}
+
+func TestFlattenDocumentsFunction_Run_InvalidDocument(t *testing.T) {
+	f := NewFlattenDocumentsFunction(nil)
+
+	resp := &function.RunResponse{}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("kind: Service\n---\nkey: : bad\n"), noOptions}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Error("Expected error for invalid second document, got nil")
+	}
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &flattenJSONFunction{}
+
+type flattenJSONFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewFlattenJSONFunction creates a new flatten_json function with the given Flattener. Falls back to defaults if nil.
+func NewFlattenJSONFunction(f *flattener.Flattener) function.Function {
+	return &flattenJSONFunction{flattener: f}
+}
+
+func (fn *flattenJSONFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "flatten_json"
+}
+
+func (fn *flattenJSONFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Flatten nested JSON content into a map with dot notation",
+		Description: "Takes JSON content as input and returns a flattened map where nested objects use dot notation (e.g., 'parent.child') and arrays use bracket notation (e.g., 'parent.array[0]'). Numbers keep their exact text, so big integers and long decimals are not rounded. An optional options object changes the separator and array notation, filters keys and transforms them.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "json_content",
+				Description: "The JSON content to flatten as a string",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: flattenJSONOptionsDescription,
+		},
+		Return: function.MapReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (fn *flattenJSONFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var jsonContent string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &jsonContent, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts jsonOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	flattenedMap, err := f.FlattenJSONStringContext(ctx, jsonContent)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten_json"), err)))
+		return
+	}
+
+	resultMap, diags := flattenedToMapValue(flattenedMap)
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result map: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(resultMap)
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFlattenJSONFunction_Metadata(t *testing.T) {
+	f := NewFlattenJSONFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "flatten_json" {
+		t.Errorf("Expected function name 'flatten_json', got %s", resp.Name)
+	}
+}
+
+func TestFlattenJSONFunction_Run(t *testing.T) {
+	f := NewFlattenJSONFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue(`{"account": {"id": 123456789012345678901234567890, "tags": ["a", "b"]}}`),
+			optionsTuple(t, map[string]attr.Value{"separator": types.StringValue("/")}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	result, ok := resp.Result.Value().(types.Map)
+	if !ok {
+		t.Fatalf("expected map result, got %T", resp.Result.Value())
+	}
+
+	expected := map[string]string{
+		"account/id":      "123456789012345678901234567890",
+		"account/tags[0]": "a",
+		"account/tags[1]": "b",
+	}
+	elements := result.Elements()
+	if len(elements) != len(expected) {
+		t.Errorf("expected %d elements, got %d", len(expected), len(elements))
+	}
+	for k, want := range expected {
+		if got := elements[k]; got == nil || !got.Equal(types.StringValue(want)) {
+			t.Errorf("element %q = %v, want %q", k, got, want)
+		}
+	}
+}
+
+func TestFlattenJSONFunction_Run_YAMLOnlyOptions(t *testing.T) {
+	for _, name := range yamlOnlyOptions {
+		t.Run(name, func(t *testing.T) {
+			f := NewFlattenJSONFunction(nil)
+
+			resp := &function.RunResponse{}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{
+					types.StringValue(`{"a": "x:\n  on: yes\n"}`),
+					optionsTuple(t, map[string]attr.Value{name: types.StringValue("yaml11")}),
+				}),
+			}, resp)
+
+			if resp.Error == nil || !strings.Contains(resp.Error.Error(), "unsupported option") {
+				t.Errorf("expected %s to be rejected as an unsupported option, got %v", name, resp.Error)
+			}
+		})
+	}
+}
+
+func TestFlattenJSONFunction_Run_InvalidJSON(t *testing.T) {
+	f := NewFlattenJSONFunction(nil)
+
+	resp := &function.RunResponse{}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("key: value"), noOptions}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Fatal("Expected error for invalid JSON content, got nil")
+	}
+	if !strings.Contains(resp.Error.Error(), "Invalid JSON Syntax") {
+		t.Errorf("Expected Invalid JSON Syntax error, got %s", resp.Error)
+	}
+}
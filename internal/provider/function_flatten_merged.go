@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &flattenMergedFunction{}
+
+type flattenMergedFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewFlattenMergedFunction creates a new flatten_merged function with the given Flattener. Falls back to defaults if nil.
+func NewFlattenMergedFunction(f *flattener.Flattener) function.Function {
+	return &flattenMergedFunction{flattener: f}
+}
+
+func (fn *flattenMergedFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "flatten_merged"
+}
+
+func (fn *flattenMergedFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Deep-merge YAML documents in order and flatten the result",
+		Description: "Takes a list of YAML documents, deep-merges them in order like Helm values files passed with -f, and returns the flattened map of the result. Objects are merged key by key, later documents win for scalars and a null in a later document deletes the key; arrays are replaced unless the array_merge option selects append, merge_by_index or merge_by_key. An optional options object changes the separator and array notation, filters keys and transforms them.",
+		Parameters: []function.Parameter{
+			function.ListParameter{
+				Name:        "layers",
+				Description: "The YAML documents to merge, lowest precedence first. Empty documents are skipped.",
+				ElementType: types.StringType,
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: "Optional object of flattening options overriding the provider defaults: array_merge (replace, append, merge_by_index or merge_by_key), array_merge_key naming the field that identifies array items for merge_by_key, and the options of flatten: separator, array_style, array_template, empty_collections, key_collisions, key_escaping, include, exclude, key_transforms, key_illegal_chars, key_replacement, key_prefix, non_string_keys, scalar_format, yaml_profile, max_alias_expansions, reject_aliases, expand_encoded and expand_encoded_keys",
+		},
+		Return: function.MapReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (fn *flattenMergedFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var contents []string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &contents, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts mergeOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	layers := make([]flattener.Layer, len(contents))
+	for i, content := range contents {
+		layers[i] = flattener.Layer{Name: fmt.Sprintf("layers[%d]", i), Content: content}
+	}
+
+	result, err := f.FlattenLayersContext(ctx, layers)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten_merged"), err)))
+		return
+	}
+
+	resultMap, diags := flattenedToMapValue(result.Values)
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result map: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(resultMap)
+}
@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFlattenMergedFunction_Metadata(t *testing.T) {
+	f := NewFlattenMergedFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "flatten_merged" {
+		t.Errorf("Expected function name 'flatten_merged', got %s", resp.Name)
+	}
+}
+
+func TestFlattenMergedFunction_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		layers   []string
+		options  types.Tuple
+		expected map[string]string
+		wantErr  string
+	}{
+		{
+			name:   "Later layers win",
+			layers: []string{"image:\n  repository: app\n  tag: latest\nports: [80]\n", "", "image:\n  tag: v2\nports: [8080]\n"},
+			expected: map[string]string{
+				"image.repository": "app",
+				"image.tag":        "v2",
+				"ports[0]":         "8080",
+			},
+		},
+		{
+			name:    "Append arrays",
+			layers:  []string{"ports: [80]\n", "ports: [8080]\n"},
+			options: optionsTuple(t, map[string]attr.Value{"array_merge": types.StringValue("append")}),
+			expected: map[string]string{
+				"ports[0]": "80",
+				"ports[1]": "8080",
+			},
+		},
+		{
+			name:   "Merge arrays by key",
+			layers: []string{"users:\n  - {name: a, role: dev}\n  - {name: b, role: dev}\n", "users:\n  - {name: b, role: ops}\n"},
+			options: optionsTuple(t, map[string]attr.Value{
+				"array_merge":     types.StringValue("merge_by_key"),
+				"array_merge_key": types.StringValue("name"),
+			}),
+			expected: map[string]string{
+				"users[0].name": "a", "users[0].role": "dev",
+				"users[1].name": "b", "users[1].role": "ops",
+			},
+		},
+		{
+			name:    "Merge by key without key",
+			layers:  []string{"a: 1\n"},
+			options: optionsTuple(t, map[string]attr.Value{"array_merge": types.StringValue("merge_by_key")}),
+			wantErr: "requires an array merge key",
+		},
+		{
+			name:    "Invalid layer",
+			layers:  []string{"a: 1\n", "a: [\n"},
+			wantErr: "layers[1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := noOptions
+			if !tt.options.IsNull() {
+				options = tt.options
+			}
+
+			layers := make([]attr.Value, len(tt.layers))
+			for i, layer := range tt.layers {
+				layers[i] = types.StringValue(layer)
+			}
+
+			f := NewFlattenMergedFunction(nil)
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.ListValueMust(types.StringType, layers), options}),
+			}, resp)
+
+			if tt.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, resp.Error)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+
+			elements := make(map[string]attr.Value, len(tt.expected))
+			for k, v := range tt.expected {
+				elements[k] = types.StringValue(v)
+			}
+			expected := types.MapValueMust(types.StringType, elements)
+			if !resp.Result.Value().Equal(expected) {
+				t.Errorf("expected %v, got %v", expected, resp.Result.Value())
+			}
+		})
+	}
+}
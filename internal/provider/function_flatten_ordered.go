@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &flattenOrderedFunction{}
+
+type flattenOrderedFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewFlattenOrderedFunction creates a new flatten_ordered function with the given Flattener. Falls back to defaults if nil.
+func NewFlattenOrderedFunction(f *flattener.Flattener) function.Function {
+	return &flattenOrderedFunction{flattener: f}
+}
+
+func (fn *flattenOrderedFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "flatten_ordered"
+}
+
+func (fn *flattenOrderedFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Flatten nested YAML content into a list of key-value pairs in document order",
+		Description: "Takes YAML content as input and returns the same keys and values as flatten as a list of {key, value} objects, in the order they appear in the document. Merged keys (<<) appear where the merge key is, and keys defined next to it keep their own position. An optional options object changes the separator and array notation, filters keys and transforms them.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "yaml_content",
+				Description: "The YAML content to flatten as a string",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: flattenOptionsDescription,
+		},
+		Return: function.ListReturn{
+			ElementType: orderedElementType,
+		},
+	}
+}
+
+func (fn *flattenOrderedFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var yamlContent string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &yamlContent, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts flattenOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	result, err := f.FlattenStringContext(ctx, yamlContent)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten_ordered"), err)))
+		return
+	}
+
+	ordered, diags := orderedToListValue(result.Ordered())
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result list: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(ordered)
+}
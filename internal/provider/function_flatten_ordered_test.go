@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFlattenOrderedFunction_Metadata(t *testing.T) {
+	f := NewFlattenOrderedFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "flatten_ordered" {
+		t.Errorf("Expected function name 'flatten_ordered', got %s", resp.Name)
+	}
+}
+
+func TestFlattenOrderedFunction_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		options  types.Tuple
+		expected [][2]string
+		wantErr  bool
+	}{
+		{
+			name: "Document order",
+			yaml: "zeta: 1\nalpha:\n  second: b\n  first: a\nitems: [z, a]\n",
+			expected: [][2]string{
+				{"zeta", "1"},
+				{"alpha.second", "b"},
+				{"alpha.first", "a"},
+				{"items[0]", "z"},
+				{"items[1]", "a"},
+			},
+		},
+		{
+			name:    "With key transforms",
+			yaml:    "b: 1\na: 2\n",
+			options: optionsTuple(t, map[string]attr.Value{"key_transforms": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("upper")})}),
+			expected: [][2]string{
+				{"B", "1"},
+				{"A", "2"},
+			},
+		},
+		{
+			name:    "Duplicate key",
+			yaml:    "a: 1\na: 2\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := noOptions
+			if !tt.options.IsNull() {
+				options = tt.options
+			}
+
+			f := NewFlattenOrderedFunction(nil)
+			resp := &function.RunResponse{Result: function.NewResultData(types.ListNull(orderedElementType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(tt.yaml), options}),
+			}, resp)
+
+			if tt.wantErr {
+				if resp.Error == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+
+			list, ok := resp.Result.Value().(types.List)
+			if !ok {
+				t.Fatalf("expected list result, got %T", resp.Result.Value())
+			}
+			elements := list.Elements()
+			if len(elements) != len(tt.expected) {
+				t.Fatalf("expected %d elements, got %d", len(tt.expected), len(elements))
+			}
+			for i, want := range tt.expected {
+				attrs := elements[i].(types.Object).Attributes()
+				if key := attrs["key"].(types.String).ValueString(); key != want[0] {
+					t.Errorf("element %d key = %q, want %q", i, key, want[0])
+				}
+				if value := attrs["value"].(types.String).ValueString(); value != want[1] {
+					t.Errorf("element %d value = %q, want %q", i, value, want[1])
+				}
+			}
+		})
+	}
+}
@@ -2,13 +2,19 @@ package provider
 
 import (
 	"context"
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
 )
 
+// noOptions is the empty variadic options argument passed when a function is called without options.
+var noOptions = types.TupleValueMust([]attr.Type{}, []attr.Value{})
+
 func TestFlattenFunction_Metadata(t *testing.T) {
 	f := NewFlattenFunction(nil)
 
@@ -40,7 +46,7 @@ func TestFlattenFunction_Run_EmptyContent(t *testing.T) {
 
 	resp := &function.RunResponse{}
 	f.Run(context.Background(), function.RunRequest{
-		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("")}),
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(""), noOptions}),
 	}, resp)
 
 	if resp.Error == nil {
@@ -53,7 +59,7 @@ func TestFlattenFunction_Run_WhitespaceOnly(t *testing.T) {
 
 	resp := &function.RunResponse{}
 	f.Run(context.Background(), function.RunRequest{
-		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("   \n   \t   ")}),
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("   \n   \t   "), noOptions}),
 	}, resp)
 
 	if resp.Error == nil {
@@ -66,10 +72,550 @@ func TestFlattenFunction_Run_InvalidYAML(t *testing.T) {
 
 	resp := &function.RunResponse{}
 	f.Run(context.Background(), function.RunRequest{
-		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("key1: value1\nkey2: [\n  invalid yaml\n")}),
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("key1: value1\nkey2: [\n  invalid yaml\n"), noOptions}),
 	}, resp)
 
 	if resp.Error == nil {
 		t.Error("Expected error for invalid YAML content, got nil")
 	}
 }
+
+func TestFlattenFunction_Run_Options(t *testing.T) {
+	yamlContent := "database:\n  replicas:\n    - host: r1\n"
+
+	tests := []struct {
+		name     string
+		options  map[string]attr.Value
+		expected string
+	}{
+		{
+			name:     "default notation",
+			options:  map[string]attr.Value{},
+			expected: "database.replicas[0].host",
+		},
+		{
+			name:     "double underscore separator",
+			options:  map[string]attr.Value{"separator": types.StringValue("__")},
+			expected: "database__replicas[0]__host",
+		},
+		{
+			name: "dotted index",
+			options: map[string]attr.Value{
+				"array_style": types.StringValue("dotted"),
+			},
+			expected: "database.replicas.0.host",
+		},
+		{
+			name: "path style",
+			options: map[string]attr.Value{
+				"separator":   types.StringValue("/"),
+				"array_style": types.StringValue("dotted"),
+			},
+			expected: "database/replicas/0/host",
+		},
+		{
+			name: "custom template",
+			options: map[string]attr.Value{
+				"separator":      types.StringValue("__"),
+				"array_template": types.StringValue("__{index}"),
+			},
+			expected: "database__replicas__0__host",
+		},
+		{
+			name: "environment variable keys",
+			options: map[string]attr.Value{
+				"key_transforms": types.TupleValueMust(
+					[]attr.Type{types.StringType, types.StringType},
+					[]attr.Value{types.StringValue("snake"), types.StringValue("upper")},
+				),
+				"key_prefix": types.StringValue("APP_"),
+			},
+			expected: "APP_DATABASE_REPLICAS_0_HOST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(yamlContent), optionsTuple(t, tt.options)}),
+			}, resp)
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+
+			result, ok := resp.Result.Value().(types.Map)
+			if !ok {
+				t.Fatalf("expected map result, got %T", resp.Result.Value())
+			}
+			if _, ok := result.Elements()[tt.expected]; !ok {
+				t.Errorf("expected key %q in result, got %v", tt.expected, result.Elements())
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_Filter(t *testing.T) {
+	f := NewFlattenFunction(nil)
+
+	patterns := func(p ...string) types.Tuple {
+		elemTypes := make([]attr.Type, len(p))
+		elems := make([]attr.Value, len(p))
+		for i, s := range p {
+			elemTypes[i] = types.StringType
+			elems[i] = types.StringValue(s)
+		}
+		return types.TupleValueMust(elemTypes, elems)
+	}
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("db:\n  host: h\n  password: p\ncache:\n  host: c\n"),
+			optionsTuple(t, map[string]attr.Value{
+				"include": patterns("db.*"),
+				"exclude": patterns("**.password"),
+			}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{"db.host": types.StringValue("h")})
+	if !resp.Result.Value().Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+	}
+}
+
+func TestFlattenFunction_Run_EmptyCollections(t *testing.T) {
+	f := NewFlattenFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("tags: {}\nitems: []\nname: app\n"),
+			optionsTuple(t, map[string]attr.Value{"empty_collections": types.StringValue("literal")}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"tags":  types.StringValue("{}"),
+		"items": types.StringValue("[]"),
+		"name":  types.StringValue("app"),
+	})
+	if !resp.Result.Value().Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+	}
+}
+
+func TestFlattenFunction_Run_KeyCollisions(t *testing.T) {
+	yamlContent := "\"a.b\": dotted\na:\n  b: nested\n"
+
+	tests := []struct {
+		policy   string
+		expected map[string]attr.Value
+		wantErr  string
+	}{
+		{policy: "error", wantErr: `key "a.b" is set more than once`},
+		{policy: "first_wins", expected: map[string]attr.Value{"a.b": types.StringValue("dotted")}},
+		{policy: "last_wins", expected: map[string]attr.Value{"a.b": types.StringValue("nested")}},
+		{policy: "escape", expected: map[string]attr.Value{`a\.b`: types.StringValue("dotted"), "a.b": types.StringValue("nested")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{
+					types.StringValue(yamlContent),
+					optionsTuple(t, map[string]attr.Value{"key_collisions": types.StringValue(tt.policy)}),
+				}),
+			}, resp)
+
+			if tt.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, resp.Error)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			expected := types.MapValueMust(types.StringType, tt.expected)
+			if !resp.Result.Value().Equal(expected) {
+				t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_KeyEscaping(t *testing.T) {
+	yamlContent := "annotations:\n  kubernetes.io/ingress.class: nginx\n"
+
+	tests := []struct {
+		escaping string
+		key      string
+	}{
+		{escaping: "none", key: "annotations.kubernetes.io/ingress.class"},
+		{escaping: "backslash", key: `annotations.kubernetes\.io/ingress\.class`},
+		{escaping: "quote", key: `annotations."kubernetes.io/ingress.class"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.escaping, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{
+					types.StringValue(yamlContent),
+					optionsTuple(t, map[string]attr.Value{"key_escaping": types.StringValue(tt.escaping)}),
+				}),
+			}, resp)
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			expected := types.MapValueMust(types.StringType, map[string]attr.Value{tt.key: types.StringValue("nginx")})
+			if !resp.Result.Value().Equal(expected) {
+				t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_NonStringKeys(t *testing.T) {
+	yamlContent := "ports:\n  80: http\n  443: https\nname: app\n"
+
+	tests := []struct {
+		policy   string
+		expected map[string]attr.Value
+		wantErr  string
+	}{
+		{policy: "canonical", expected: map[string]attr.Value{
+			"ports.80":  types.StringValue("http"),
+			"ports.443": types.StringValue("https"),
+			"name":      types.StringValue("app"),
+		}},
+		{policy: "skip", expected: map[string]attr.Value{"name": types.StringValue("app")}},
+		{policy: "reject", wantErr: "non-string key 80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{
+					types.StringValue(yamlContent),
+					optionsTuple(t, map[string]attr.Value{"non_string_keys": types.StringValue(tt.policy)}),
+				}),
+			}, resp)
+
+			if tt.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, resp.Error)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			expected := types.MapValueMust(types.StringType, tt.expected)
+			if !resp.Result.Value().Equal(expected) {
+				t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_ScalarFormat(t *testing.T) {
+	yamlContent := "mask: 0x1F\nhuge: 1e21\ndate: 2026-01-02\n"
+
+	tests := []struct {
+		format   string
+		expected map[string]attr.Value
+	}{
+		{format: "canonical", expected: map[string]attr.Value{
+			"mask": types.StringValue("31"),
+			"huge": types.StringValue("1e+21"),
+			"date": types.StringValue("2026-01-02T00:00:00Z"),
+		}},
+		{format: "preserve", expected: map[string]attr.Value{
+			"mask": types.StringValue("0x1F"),
+			"huge": types.StringValue("1e21"),
+			"date": types.StringValue("2026-01-02"),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{
+					types.StringValue(yamlContent),
+					optionsTuple(t, map[string]attr.Value{"scalar_format": types.StringValue(tt.format)}),
+				}),
+			}, resp)
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			expected := types.MapValueMust(types.StringType, tt.expected)
+			if !resp.Result.Value().Equal(expected) {
+				t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_YAMLProfile(t *testing.T) {
+	yamlContent := "enabled: on\nmode: 012\n"
+
+	tests := []struct {
+		profile  string
+		expected map[string]attr.Value
+	}{
+		{profile: "default", expected: map[string]attr.Value{"enabled": types.StringValue("on"), "mode": types.StringValue("10")}},
+		{profile: "yaml12", expected: map[string]attr.Value{"enabled": types.StringValue("on"), "mode": types.StringValue("12")}},
+		{profile: "yaml11", expected: map[string]attr.Value{"enabled": types.StringValue("true"), "mode": types.StringValue("10")}},
+		{profile: "strings", expected: map[string]attr.Value{"enabled": types.StringValue("on"), "mode": types.StringValue("012")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{
+					types.StringValue(yamlContent),
+					optionsTuple(t, map[string]attr.Value{"yaml_profile": types.StringValue(tt.profile)}),
+				}),
+			}, resp)
+
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			expected := types.MapValueMust(types.StringType, tt.expected)
+			if !resp.Result.Value().Equal(expected) {
+				t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_ExpandEncoded(t *testing.T) {
+	f := NewFlattenFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("policy: '{\"Version\": \"2012-10-17\"}'\nnote: '{\"kept\": true}'\n"),
+			optionsTuple(t, map[string]attr.Value{
+				"expand_encoded":      types.BoolValue(true),
+				"expand_encoded_keys": types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("policy")}),
+			}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"policy.Version": types.StringValue("2012-10-17"),
+		"note":           types.StringValue(`{"kept": true}`),
+	})
+	if !resp.Result.Value().Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, resp.Result.Value())
+	}
+}
+
+func TestFlattenFunction_Run_Aliases(t *testing.T) {
+	yamlContent := "base: &base {a: 1, b: 2}\ncopy: *base\n"
+
+	tests := []struct {
+		name    string
+		options map[string]attr.Value
+		wantErr string
+	}{
+		{
+			name:    "aliases allowed",
+			options: map[string]attr.Value{},
+		},
+		{
+			name:    "aliases rejected",
+			options: map[string]attr.Value{"reject_aliases": types.BoolValue(true)},
+			wantErr: "alias *base is not allowed",
+		},
+		{
+			name:    "expansion budget",
+			options: map[string]attr.Value{"max_alias_expansions": types.NumberValue(big.NewFloat(2))},
+			wantErr: "maximum of 2 alias expansions exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(yamlContent), optionsTuple(t, tt.options)}),
+			}, resp)
+
+			if tt.wantErr == "" {
+				if resp.Error != nil {
+					t.Fatalf("unexpected error: %s", resp.Error)
+				}
+				return
+			}
+			if resp.Error == nil || !strings.Contains(resp.Error.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, resp.Error)
+			}
+		})
+	}
+}
+
+func TestFlattenFunction_Run_LimitDetail(t *testing.T) {
+	base := flattener.New()
+	base.MaxResultSize = 1
+	f := NewFlattenFunction(base)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("a: 1\nb: 2\n"), noOptions}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Fatal("expected size limit error, got nil")
+	}
+	if !strings.Contains(resp.Error.Error(), "The effective max_result_size is 1. Set max_result_size in the provider configuration to change it.") {
+		t.Errorf("expected the effective limit in the error, got %s", resp.Error)
+	}
+
+	base = flattener.New()
+	base.MaxAliasExpansions = 1
+	f = NewFlattenFunction(base)
+
+	resp = &function.RunResponse{Result: function.NewResultData(types.MapNull(types.StringType))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("a: &a {x: 1, y: 2}\nb: *a\n"), noOptions}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Fatal("expected alias limit error, got nil")
+	}
+	if !strings.Contains(resp.Error.Error(), "Set max_alias_expansions in the provider configuration or in the options of the flatten function to change it.") {
+		t.Errorf("expected the function options in the error, got %s", resp.Error)
+	}
+}
+
+func TestFlattenFunction_Run_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]attr.Value
+	}{
+		{
+			name:    "unknown option",
+			options: map[string]attr.Value{"bogus": types.StringValue("x")},
+		},
+		{
+			name:    "empty separator",
+			options: map[string]attr.Value{"separator": types.StringValue("")},
+		},
+		{
+			name:    "unsupported array style",
+			options: map[string]attr.Value{"array_style": types.StringValue("parens")},
+		},
+		{
+			name:    "template without placeholder",
+			options: map[string]attr.Value{"array_template": types.StringValue("__")},
+		},
+		{
+			name:    "invalid regex pattern",
+			options: map[string]attr.Value{"include": types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("regex:(")})},
+		},
+		{
+			name:    "non-list patterns",
+			options: map[string]attr.Value{"exclude": types.StringValue("**.password")},
+		},
+		{
+			name:    "unsupported key transform",
+			options: map[string]attr.Value{"key_transforms": types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("title")})},
+		},
+		{
+			name:    "non-string value",
+			options: map[string]attr.Value{"separator": types.BoolValue(true)},
+		},
+		{
+			name:    "fractional alias budget",
+			options: map[string]attr.Value{"max_alias_expansions": types.NumberValue(big.NewFloat(1.5))},
+		},
+		{
+			name:    "negative alias budget",
+			options: map[string]attr.Value{"max_alias_expansions": types.NumberValue(big.NewFloat(-1))},
+		},
+		{
+			name:    "non-bool reject_aliases",
+			options: map[string]attr.Value{"reject_aliases": types.StringValue("yes")},
+		},
+		{
+			name:    "merge option",
+			options: map[string]attr.Value{"array_merge": types.StringValue("append")},
+		},
+		{
+			name:    "unknown empty collections policy",
+			options: map[string]attr.Value{"empty_collections": types.StringValue("null")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlattenFunction(nil)
+
+			resp := &function.RunResponse{}
+			f.Run(context.Background(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("key: value"), optionsTuple(t, tt.options)}),
+			}, resp)
+
+			if resp.Error == nil {
+				t.Error("Expected error for invalid options, got nil")
+			}
+		})
+	}
+}
+
+// optionsTuple builds the variadic argument for a single options object.
+func optionsTuple(t *testing.T, options map[string]attr.Value) types.Tuple {
+	t.Helper()
+
+	attrTypes := make(map[string]attr.Type, len(options))
+	for k, v := range options {
+		attrTypes[k] = v.Type(context.Background())
+	}
+	obj, diags := types.ObjectValue(attrTypes, options)
+	if diags.HasError() {
+		t.Fatalf("failed to build options object: %v", diags)
+	}
+
+	return types.TupleValueMust(
+		[]attr.Type{types.DynamicType},
+		[]attr.Value{types.DynamicValue(obj)},
+	)
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &flattenTypedFunction{}
+
+type flattenTypedFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewFlattenTypedFunction creates a new flatten_typed function with the given Flattener. Falls back to defaults if nil.
+func NewFlattenTypedFunction(f *flattener.Flattener) function.Function {
+	return &flattenTypedFunction{flattener: f}
+}
+
+func (fn *flattenTypedFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "flatten_typed"
+}
+
+func (fn *flattenTypedFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Flatten nested YAML content into an object that keeps value types",
+		Description: "Takes YAML content as input and returns an object with the same keys as flatten, where numbers, bools and nulls keep their type instead of being converted to strings. An optional options object changes the separator and array notation, filters keys and transforms them.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "yaml_content",
+				Description: "The YAML content to flatten as a string",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: flattenOptionsDescription,
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+func (fn *flattenTypedFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var yamlContent string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &yamlContent, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts flattenOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	result, err := f.FlattenStringContext(ctx, yamlContent)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten_typed"), err)))
+		return
+	}
+
+	typedObject, diags := typedToObjectValue(ctx, result)
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result object: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(types.DynamicValue(typedObject))
+}
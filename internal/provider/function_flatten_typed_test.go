@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFlattenTypedFunction_Metadata(t *testing.T) {
+	f := NewFlattenTypedFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "flatten_typed" {
+		t.Errorf("Expected function name 'flatten_typed', got %s", resp.Name)
+	}
+}
+
+func TestFlattenTypedFunction_Run(t *testing.T) {
+	f := NewFlattenTypedFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.DynamicNull())}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("port: 8080\nratio: 0.5\nenabled: true\nname: app\nempty: null\nbig: 18446744073709551615\ninf: .inf\n"),
+			noOptions,
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	dynamic, ok := resp.Result.Value().(types.Dynamic)
+	if !ok {
+		t.Fatalf("expected dynamic result, got %T", resp.Result.Value())
+	}
+	object, ok := dynamic.UnderlyingValue().(types.Object)
+	if !ok {
+		t.Fatalf("expected object value, got %T", dynamic.UnderlyingValue())
+	}
+	attrs := object.Attributes()
+
+	bigValue, _ := new(big.Float).SetPrec(512).SetString("18446744073709551615")
+	expected := map[string]attr.Value{
+		"port":    types.NumberValue(big.NewFloat(8080)),
+		"ratio":   types.NumberValue(big.NewFloat(0.5)),
+		"enabled": types.BoolValue(true),
+		"name":    types.StringValue("app"),
+		"empty":   types.StringNull(),
+		"big":     types.NumberValue(bigValue),
+		"inf":     types.StringValue(".inf"),
+	}
+
+	for k, want := range expected {
+		if got := attrs[k]; got == nil || !got.Equal(want) {
+			t.Errorf("attribute %q = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestFlattenTypedFunction_Run_EmptyCollections(t *testing.T) {
+	f := NewFlattenTypedFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.DynamicNull())}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("tags: {}\nitems: []\n"),
+			optionsTuple(t, map[string]attr.Value{"empty_collections": types.StringValue("empty_string")}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	attrs := resp.Result.Value().(types.Dynamic).UnderlyingValue().(types.Object).Attributes()
+	if _, ok := attrs["tags"].(types.Object); !ok {
+		t.Errorf("tags = %v, want an empty object", attrs["tags"])
+	}
+	if _, ok := attrs["items"].(types.Tuple); !ok {
+		t.Errorf("items = %v, want an empty tuple", attrs["items"])
+	}
+}
+
+func TestFlattenTypedFunction_Run_PreserveScalars(t *testing.T) {
+	f := NewFlattenTypedFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.DynamicNull())}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("mask: 0x1F\nratio: 1.50\nenabled: True\ndate: 2026-01-02\n"),
+			optionsTuple(t, map[string]attr.Value{"scalar_format": types.StringValue("preserve")}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	attrs := resp.Result.Value().(types.Dynamic).UnderlyingValue().(types.Object).Attributes()
+	expected := map[string]attr.Value{
+		"ratio":   types.NumberValue(big.NewFloat(1.5)),
+		"enabled": types.BoolValue(true),
+		"date":    types.StringValue("2026-01-02"),
+	}
+	for k, want := range expected {
+		if got := attrs[k]; got == nil || !got.Equal(want) {
+			t.Errorf("attribute %q = %v, want %v", k, got, want)
+		}
+	}
+}
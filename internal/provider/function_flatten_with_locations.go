@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &flattenWithLocationsFunction{}
+
+var withLocationsAttrTypes = map[string]attr.Type{
+	"flattened": types.MapType{ElemType: types.StringType},
+	"locations": types.MapType{ElemType: types.StringType},
+}
+
+type flattenWithLocationsFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewFlattenWithLocationsFunction creates a new flatten_with_locations function with the given Flattener. Falls back to defaults if nil.
+func NewFlattenWithLocationsFunction(f *flattener.Flattener) function.Function {
+	return &flattenWithLocationsFunction{flattener: f}
+}
+
+func (fn *flattenWithLocationsFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "flatten_with_locations"
+}
+
+func (fn *flattenWithLocationsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Flatten nested YAML content and report where each value is defined",
+		Description: "Takes YAML content as input and returns an object with two maps: flattened, the same map as flatten, and locations, the line:column of every flattened value in the YAML content. Keys merged with << or copied by an alias point at the value they were copied from. An optional options object changes the separator and array notation, filters keys and transforms them.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "yaml_content",
+				Description: "The YAML content to flatten as a string",
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: flattenOptionsDescription,
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: withLocationsAttrTypes,
+		},
+	}
+}
+
+func (fn *flattenWithLocationsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var yamlContent string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &yamlContent, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts flattenOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	result, err := f.FlattenStringContext(ctx, yamlContent)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError(errorTitle(err)+": "+errorDetail(f, functionCaller("flatten_with_locations"), err)))
+		return
+	}
+
+	flattened, diags := flattenedToMapValue(result.Values)
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result map: "+diags[0].Detail()))
+		return
+	}
+
+	locations, diags := locationsToMapValue(result.Locations)
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create locations map: "+diags[0].Detail()))
+		return
+	}
+
+	object, diags := types.ObjectValue(withLocationsAttrTypes, map[string]attr.Value{
+		"flattened": flattened,
+		"locations": locations,
+	})
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result object: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(object)
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFlattenWithLocationsFunction_Metadata(t *testing.T) {
+	f := NewFlattenWithLocationsFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "flatten_with_locations" {
+		t.Errorf("Expected function name 'flatten_with_locations', got %s", resp.Name)
+	}
+}
+
+func TestFlattenWithLocationsFunction_Run(t *testing.T) {
+	f := NewFlattenWithLocationsFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.ObjectNull(withLocationsAttrTypes))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("server:\n  host: example.com\n  ports: [80, 443]\n"),
+			noOptions,
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	object, ok := resp.Result.Value().(types.Object)
+	if !ok {
+		t.Fatalf("expected object result, got %T", resp.Result.Value())
+	}
+	attrs := object.Attributes()
+
+	flattened := attrs["flattened"].(types.Map).Elements()
+	if got := flattened["server.host"]; !got.Equal(types.StringValue("example.com")) {
+		t.Errorf("flattened[server.host] = %v, want example.com", got)
+	}
+
+	expected := map[string]string{
+		"server.host":     "2:9",
+		"server.ports[0]": "3:11",
+		"server.ports[1]": "3:15",
+	}
+	locations := attrs["locations"].(types.Map).Elements()
+	if len(locations) != len(expected) {
+		t.Fatalf("expected %d locations, got %d", len(expected), len(locations))
+	}
+	for k, want := range expected {
+		if got := locations[k]; got == nil || !got.Equal(types.StringValue(want)) {
+			t.Errorf("locations[%q] = %v, want %q", k, got, want)
+		}
+	}
+}
+
+func TestFlattenWithLocationsFunction_InvalidYAML(t *testing.T) {
+	f := NewFlattenWithLocationsFunction(nil)
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.ObjectNull(withLocationsAttrTypes))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("a: : b"), noOptions}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
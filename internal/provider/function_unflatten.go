@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-yamlflattener/internal/flattener"
+)
+
+var _ function.Function = &unflattenFunction{}
+
+// unflattenResultAttrTypes describes the object returned by the unflatten function.
+var unflattenResultAttrTypes = map[string]attr.Type{
+	"yaml": types.StringType,
+	"json": types.StringType,
+}
+
+type unflattenFunction struct {
+	flattener *flattener.Flattener
+}
+
+// NewUnflattenFunction creates a new unflatten function with the given Flattener. Falls back to defaults if nil.
+func NewUnflattenFunction(f *flattener.Flattener) function.Function {
+	return &unflattenFunction{flattener: f}
+}
+
+func (fn *unflattenFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "unflatten"
+}
+
+func (fn *unflattenFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Rebuild nested YAML and JSON from a flattened map",
+		Description: "Takes a flat map whose keys use dot notation for objects and bracket notation for arrays (the output of flatten) and returns an object with the equivalent nested document encoded as yaml and json strings. An optional options object selects the separator and array notation used by the keys.",
+		Parameters: []function.Parameter{
+			function.MapParameter{
+				Name:        "flattened",
+				Description: "The flattened map to rebuild",
+				ElementType: types.StringType,
+			},
+		},
+		VariadicParameter: function.DynamicParameter{
+			Name:        "options",
+			Description: "Optional object of key notation options overriding the provider defaults: separator, array_style (brackets, dotted or template), array_template, empty_collections (literal rebuilds {} and [] values as empty objects and arrays), key_collisions (escape undoes the key escapes of flatten) and key_escaping (backslash or quote, undoing the key escapes of flatten)",
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: unflattenResultAttrTypes,
+		},
+	}
+}
+
+func (fn *unflattenFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var flat map[string]string
+	var options []types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &flat, &options))
+	if resp.Error != nil {
+		return
+	}
+
+	var opts notationOptionsModel
+	if err := functionOptions(ctx, options, &opts); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, "Invalid Options: "+err.Error())
+		return
+	}
+
+	f, err := withOptions(ctx, fn.flattener, &opts)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, errorTitle(err)+": "+err.Error())
+		return
+	}
+
+	data, err := f.Unflatten(flat)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, errorTitle(err)+": "+errorDetail(f, unflattenCaller, err))
+		return
+	}
+
+	yamlOut, err := flattener.UnflattenedToYAML(data)
+	if err != nil {
+		resp.Error = function.NewFuncError(errorTitle(err) + ": " + err.Error())
+		return
+	}
+
+	jsonOut, err := flattener.UnflattenedToJSON(data)
+	if err != nil {
+		resp.Error = function.NewFuncError(errorTitle(err) + ": " + err.Error())
+		return
+	}
+
+	result, diags := types.ObjectValue(unflattenResultAttrTypes, map[string]attr.Value{
+		"yaml": types.StringValue(yamlOut),
+		"json": types.StringValue(jsonOut),
+	})
+	if diags.HasError() {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewFuncError("Failed to create result object: "+diags[0].Detail()))
+		return
+	}
+
+	resp.Result = function.NewResultData(result)
+}
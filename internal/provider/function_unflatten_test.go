@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestUnflattenFunction_Metadata(t *testing.T) {
+	f := NewUnflattenFunction(nil)
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+
+	if resp.Name != "unflatten" {
+		t.Errorf("Expected function name 'unflatten', got %s", resp.Name)
+	}
+}
+
+func TestUnflattenFunction_Run(t *testing.T) {
+	f := NewUnflattenFunction(nil)
+
+	flat := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"database.host":        types.StringValue("localhost"),
+		"database.replicas[0]": types.StringValue("r1"),
+	})
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.ObjectNull(unflattenResultAttrTypes))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{flat, noOptions}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	result, ok := resp.Result.Value().(types.Object)
+	if !ok {
+		t.Fatalf("expected object result, got %T", resp.Result.Value())
+	}
+
+	expectedJSON := `{"database":{"host":"localhost","replicas":["r1"]}}`
+	if got := result.Attributes()["json"].(types.String).ValueString(); got != expectedJSON {
+		t.Errorf("Expected json %s, got %s", expectedJSON, got)
+	}
+
+	expectedYAML := "database:\n    host: localhost\n    replicas:\n        - r1\n"
+	if got := result.Attributes()["yaml"].(types.String).ValueString(); got != expectedYAML {
+		t.Errorf("Expected yaml %q, got %q", expectedYAML, got)
+	}
+}
+
+func TestUnflattenFunction_Run_Conflict(t *testing.T) {
+	f := NewUnflattenFunction(nil)
+
+	flat := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"a":   types.StringValue("x"),
+		"a.b": types.StringValue("y"),
+	})
+
+	resp := &function.RunResponse{}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{flat, noOptions}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Error("Expected error for conflicting keys, got nil")
+	}
+}
+
+func TestUnflattenFunction_Run_EmptyCollections(t *testing.T) {
+	f := NewUnflattenFunction(nil)
+
+	flat := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"tags":  types.StringValue("{}"),
+		"items": types.StringValue("[]"),
+	})
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.ObjectNull(unflattenResultAttrTypes))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			flat,
+			optionsTuple(t, map[string]attr.Value{"empty_collections": types.StringValue("literal")}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	result := resp.Result.Value().(types.Object)
+	expectedJSON := `{"items":[],"tags":{}}`
+	if got := result.Attributes()["json"].(types.String).ValueString(); got != expectedJSON {
+		t.Errorf("Expected json %s, got %s", expectedJSON, got)
+	}
+}
+
+func TestUnflattenFunction_Run_KeyEscaping(t *testing.T) {
+	f := NewUnflattenFunction(nil)
+
+	flat := types.MapValueMust(types.StringType, map[string]attr.Value{
+		`annotations."kubernetes.io/ingress.class"`: types.StringValue("nginx"),
+	})
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.ObjectNull(unflattenResultAttrTypes))}
+	f.Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			flat,
+			optionsTuple(t, map[string]attr.Value{"key_escaping": types.StringValue("quote")}),
+		}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	result := resp.Result.Value().(types.Object)
+	expectedJSON := `{"annotations":{"kubernetes.io/ingress.class":"nginx"}}`
+	if got := result.Attributes()["json"].(types.String).ValueString(); got != expectedJSON {
+		t.Errorf("Expected json %s, got %s", expectedJSON, got)
+	}
+}
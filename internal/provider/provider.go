@@ -27,8 +27,11 @@ type YAMLFlattenerProvider struct {
 
 // YAMLFlattenerProviderModel describes the provider data model.
 type YAMLFlattenerProviderModel struct {
-	// Optional configuration fields can be added here if needed in the future
-	MaxDepth types.Int64 `tfsdk:"max_depth"`
+	MaxAliasExpansions types.Int64  `tfsdk:"max_alias_expansions"`
+	AllowedBaseDirs    types.List   `tfsdk:"allowed_base_dirs"`
+	SymlinkPolicy      types.String `tfsdk:"symlink_policy"`
+	limitsModel
+	defaultOptionsModel
 }
 
 // Metadata returns the provider metadata including type name and version.
@@ -42,10 +45,75 @@ func (p *YAMLFlattenerProvider) Metadata(_ context.Context, _ provider.MetadataR
 // Schema defines the provider schema and configuration options.
 func (p *YAMLFlattenerProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "The YAML Flattener provider allows you to flatten nested YAML structures into flat key-value maps with dot notation for nested objects and bracket notation for arrays.",
+		Description: "The YAML Flattener provider allows you to flatten nested YAML structures into flat key-value maps with dot notation for nested objects and bracket notation for arrays, and to rebuild nested documents from such maps.",
 		Attributes: map[string]schema.Attribute{
 			"max_depth": schema.Int64Attribute{
-				Description: "Maximum recursion depth for flattening (default: 100). Set to prevent stack overflow with deeply nested structures.",
+				Description: "Maximum recursion depth for flattening (default: 100, at most 10000). Set to prevent stack overflow with deeply nested structures.",
+				Optional:    true,
+			},
+			"max_result_size": schema.Int64Attribute{
+				Description: "Maximum number of flattened keys in one result (default: 100000, at most 10000000).",
+				Optional:    true,
+			},
+			"max_input_size": schema.Int64Attribute{
+				Description: "Maximum size of the content or file to flatten, in bytes (default: 10485760, i.e. 10 MiB, at most 1 GiB).",
+				Optional:    true,
+			},
+			"max_key_length": schema.Int64Attribute{
+				Description: "Length in bytes at which a single map key is truncated (default: 1000, at most 65536).",
+				Optional:    true,
+			},
+			"max_alias_expansions": schema.Int64Attribute{
+				Description: "Maximum number of YAML nodes and merged keys copied through aliases (default: 100000, at most 100000000).",
+				Optional:    true,
+			},
+			"timeout": schema.StringAttribute{
+				Description: "Maximum time spent parsing and flattening one input, as a duration such as \"30s\" or \"2m\" (default: \"5s\", at most \"1h\"). \"0s\" disables the limit, leaving only Terraform's own cancellation.",
+				Optional:    true,
+			},
+			"allowed_base_dirs": schema.ListAttribute{
+				Description: "Directories that file inputs must be inside. Files and directories outside every listed directory are rejected with a security error. Relative directories are resolved against the working directory of Terraform. By default any path without directory traversal is allowed.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"symlink_policy": schema.StringAttribute{
+				Description: "Which symbolic links file paths may go through: \"follow\" (the default) follows every link, but only inside allowed_base_dirs when it is set, \"within_base\" only links that resolve inside allowed_base_dirs, and \"reject\" refuses every link below the allowed base directories.",
+				Optional:    true,
+			},
+			"separator": schema.StringAttribute{
+				Description: "Default string placed between nested object keys (default: \".\"). For example \"__\" yields database__primary__host and \"/\" yields database/primary/host.",
+				Optional:    true,
+			},
+			"array_style": schema.StringAttribute{
+				Description: "Default array index notation: \"brackets\" (items[0], the default), \"dotted\" (items.0, joined with the separator) or \"template\" (uses array_template).",
+				Optional:    true,
+			},
+			"array_template": schema.StringAttribute{
+				Description: "Default custom array index notation appended to the parent key, containing the {index} placeholder (e.g. \"__{index}\"). Setting it implies array_style = \"template\".",
+				Optional:    true,
+			},
+			"empty_collections": schema.StringAttribute{
+				Description: "Default for empty objects and arrays: \"omit\" (the default) leaves them out of the result, \"literal\" keeps them as \"{}\" and \"[]\", which unflatten turns back into empty objects and arrays, and \"empty_string\" keeps them as empty strings.",
+				Optional:    true,
+			},
+			"key_collisions": schema.StringAttribute{
+				Description: "Default for two values that flatten to the same key, such as \"a.b\": x next to a: {b: y}: \"error\" (the default), \"warn\" (keep the first value and report a warning on data sources), \"first_wins\", \"last_wins\" or \"escape\" (escape keys as key_escaping does, with a backslash unless key_escaping is set, which unflatten undoes).",
+				Optional:    true,
+			},
+			"key_escaping": schema.StringAttribute{
+				Description: "Default for object keys containing the separator or an array marker, such as kubernetes.io/ingress.class: \"none\" (the default) keeps them as they are, \"backslash\" escapes the separator, brackets and backslashes with a backslash and \"quote\" wraps such keys in double quotes. Unflatten undoes both.",
+				Optional:    true,
+			},
+			"non_string_keys": schema.StringAttribute{
+				Description: "Default for int, float, bool, null and timestamp map keys, such as the 80 of ports: {80: http}: \"canonical\" (the default) writes them like flattened values, \"reject\" fails naming the key and \"skip\" leaves their values out.",
+				Optional:    true,
+			},
+			"scalar_format": schema.StringAttribute{
+				Description: "Default form of flattened YAML scalars: \"canonical\" (the default) uses one form per type (ints in base 10, RFC 3339 timestamps, .inf and .nan) and \"preserve\" keeps the text as written in the source (0x1F, 1e21, ~).",
+				Optional:    true,
+			},
+			"yaml_profile": schema.StringAttribute{
+				Description: "Default schema deciding the type of plain YAML scalars: \"default\" resolves them like gopkg.in/yaml.v3 (YAML 1.2 core plus YAML 1.1 integers and timestamps), \"yaml12\" uses the strict YAML 1.2 core schema, \"yaml11\" the YAML 1.1 types of Helm and Kubernetes (yes, on, no and off are booleans, 012 is octal) and \"strings\" reads every plain scalar as a string.",
 				Optional:    true,
 			},
 		},
@@ -64,8 +132,27 @@ func (p *YAMLFlattenerProvider) Configure(ctx context.Context, req provider.Conf
 	}
 
 	f := flattener.New()
-	if !data.MaxDepth.IsNull() {
-		f.MaxNestingDepth = int(data.MaxDepth.ValueInt64())
+	if err := setLimit(&f.MaxAliasExpansions, "max_alias_expansions", data.MaxAliasExpansions, 0, flattener.MaxAliasExpansionsCeiling); err != nil {
+		resp.Diagnostics.AddError("Invalid Provider Configuration", err.Error())
+		return
+	}
+	if !data.AllowedBaseDirs.IsNull() {
+		if diags := data.AllowedBaseDirs.ElementsAs(ctx, &f.AllowedBaseDirs, false); diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+	}
+	if !data.SymlinkPolicy.IsNull() {
+		f.SymlinkPolicy = flattener.SymlinkPolicy(data.SymlinkPolicy.ValueString())
+	}
+	if err := data.limitsModel.apply(ctx, f); err != nil {
+		resp.Diagnostics.AddError("Invalid Provider Configuration", err.Error())
+		return
+	}
+
+	if err := data.defaultOptionsModel.apply(ctx, f); err != nil {
+		resp.Diagnostics.AddError("Invalid Provider Configuration", err.Error())
+		return
 	}
 
 	p.flattener = f
@@ -83,6 +170,8 @@ func (p *YAMLFlattenerProvider) Resources(_ context.Context) []func() resource.R
 func (p *YAMLFlattenerProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewFlattenDataSource,
+		NewFlattenDirectoryDataSource,
+		NewUnflattenDataSource,
 	}
 }
 
@@ -90,6 +179,16 @@ func (p *YAMLFlattenerProvider) DataSources(_ context.Context) []func() datasour
 func (p *YAMLFlattenerProvider) Functions(_ context.Context) []func() function.Function {
 	return []func() function.Function{
 		func() function.Function { return NewFlattenFunction(p.flattener) },
+		func() function.Function { return NewFlattenTypedFunction(p.flattener) },
+		func() function.Function { return NewFlattenOrderedFunction(p.flattener) },
+		func() function.Function { return NewFlattenWithLocationsFunction(p.flattener) },
+		func() function.Function { return NewFlattenMergedFunction(p.flattener) },
+		func() function.Function { return NewFlattenDocumentsFunction(p.flattener) },
+		func() function.Function { return NewFlattenJSONFunction(p.flattener) },
+		func() function.Function { return NewUnflattenFunction(p.flattener) },
+		NewToDotenvFunction,
+		NewToPropertiesFunction,
+		NewToJSONFunction,
 	}
 }
 
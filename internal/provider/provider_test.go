@@ -1,11 +1,16 @@
 package provider
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"terraform-provider-yamlflattener/internal/flattener"
 )
 
 // testAccProtoV6ProviderFactories are used to instantiate a provider during
@@ -36,7 +41,10 @@ func TestProviderConfigure(t *testing.T) {
 			{
 				Config: `
 provider "yamlflattener" {
-  max_depth = 50
+  max_depth       = 50
+  max_result_size = 500000
+  max_input_size  = 67108864
+  timeout         = "30s"
 }
 `,
 				Check: resource.ComposeAggregateTestCheckFunc(
@@ -96,3 +104,144 @@ output "flattened" {
 		},
 	})
 }
+
+func TestLimitsModel_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  limitsModel
+		check   func(f *flattener.Flattener) bool
+		wantErr string
+	}{
+		{
+			name:   "raised limits",
+			limits: limitsModel{MaxResultSize: types.Int64Value(500000), MaxInputSize: types.Int64Value(64 << 20), Timeout: types.StringValue("1m")},
+			check: func(f *flattener.Flattener) bool {
+				return f.MaxResultSize == 500000 && f.MaxYAMLSize == 64<<20 && f.Timeout == time.Minute && f.MaxNestingDepth == flattener.MaxNestingDepth
+			},
+		},
+		{
+			name:    "zero depth",
+			limits:  limitsModel{MaxDepth: types.Int64Value(0)},
+			wantErr: "max_depth must be between 1 and 10000, got 0",
+		},
+		{
+			name:    "result size above ceiling",
+			limits:  limitsModel{MaxResultSize: types.Int64Value(flattener.MaxResultSizeCeiling + 1)},
+			wantErr: "max_result_size must be between",
+		},
+		{
+			name:    "negative key length",
+			limits:  limitsModel{MaxKeyLength: types.Int64Value(-5)},
+			wantErr: "max_key_length must be between",
+		},
+		{
+			name:    "timeout above ceiling",
+			limits:  limitsModel{Timeout: types.StringValue("2h")},
+			wantErr: "timeout must be between",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flattener.New()
+			err := tt.limits.apply(context.Background(), f)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.check(f) {
+				t.Errorf("unexpected limits: %+v", f)
+			}
+		})
+	}
+}
+
+func TestDefaultOptionsModel_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		options defaultOptionsModel
+		check   func(f *flattener.Flattener) bool
+		wantErr string
+	}{
+		{
+			name: "scalar options",
+			options: defaultOptionsModel{
+				NonStringKeys: types.StringValue("reject"),
+				ScalarFormat:  types.StringValue("preserve"),
+				YAMLProfile:   types.StringValue("yaml11"),
+			},
+			check: func(f *flattener.Flattener) bool {
+				return f.NonStringKeys == flattener.NonStringKeysReject && f.ScalarFormat == flattener.ScalarFormatPreserve && f.YAMLProfile == flattener.YAMLProfileYAML11
+			},
+		},
+		{
+			name:    "notation options",
+			options: defaultOptionsModel{notationOptionsModel: notationOptionsModel{Separator: types.StringValue("/")}},
+			check: func(f *flattener.Flattener) bool {
+				return f.Separator == "/" && f.YAMLProfile == flattener.YAMLProfileDefault
+			},
+		},
+		{
+			name:    "unknown profile",
+			options: defaultOptionsModel{YAMLProfile: types.StringValue("yaml13")},
+			wantErr: "yaml13",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := flattener.New()
+			err := tt.options.apply(context.Background(), f)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.check(f) {
+				t.Errorf("unexpected options: %+v", f)
+			}
+		})
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{value: "30s", expected: 30 * time.Second},
+		{value: "1m30s", expected: 90 * time.Second},
+		{value: "0s", expected: 0},
+		{value: "30", wantErr: true},
+		{value: "-1s", wantErr: true},
+		{value: "61m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			timeout, err := parseTimeout(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got %s", tt.value, timeout)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if timeout != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, timeout)
+			}
+		})
+	}
+}